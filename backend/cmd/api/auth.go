@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/auth"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// jwtVerifier checks Supabase session tokens. It is nil when
+// SUPABASE_JWT_SECRET is unset, in which case only API tokens are accepted.
+var jwtVerifier *auth.Verifier
+
+// botTokenHash is the hash of BOT_API_TOKEN, the token the Discord bot uses.
+// It is empty when the bot token is not configured.
+var botTokenHash string
+
+// users resolves tokens and league membership. It is the database when one
+// is configured, and nil otherwise; tests stand in their own.
+var users userStore
+
+// userStore is the part of the database that authentication reads
+type userStore interface {
+	GetUserByAPIToken(ctx context.Context, tokenHash string) (*models.User, error)
+	LinkAuthUser(ctx context.Context, authID, email, displayName string) (*models.User, error)
+	IsLeagueMember(ctx context.Context, leagueID string, userID int) (bool, error)
+}
+
+// principal is who a request is made by: a user, or the Discord bot acting
+// for every league
+type principal struct {
+	User *models.User
+	Bot  bool
+}
+
+type principalKey struct{}
+
+// setupAuth prepares token checks from the auth settings
+func setupAuth(cfg apiConfig) {
+	if cfg.JWTSecret != "" {
+		jwtVerifier = auth.NewVerifier(cfg.JWTSecret, "authenticated")
+	} else {
+		slog.Warn("SUPABASE_JWT_SECRET not set, only API tokens will be accepted")
+	}
+	if cfg.BotAPIToken != "" {
+		botTokenHash = auth.HashAPIToken(cfg.BotAPIToken)
+	}
+}
+
+// currentPrincipal returns who made the request. It is only set on routes
+// behind requireAuth.
+func currentPrincipal(r *http.Request) *principal {
+	p, _ := r.Context().Value(principalKey{}).(*principal)
+	return p
+}
+
+// requireAuth rejects requests without a valid bearer token: a Supabase
+// session JWT, a user's API token, or the bot token
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			writeUnauthorized(w, "missing bearer token")
+			return
+		}
+
+		p, status, msg := authenticate(r.Context(), token)
+		if p == nil {
+			if status == http.StatusUnauthorized {
+				writeUnauthorized(w, msg)
+			} else {
+				writeError(w, status, msg)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey{}, p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate resolves a bearer token, returning the status and message to
+// respond with when it is not accepted
+func authenticate(ctx context.Context, token string) (*principal, int, string) {
+	if isBotToken(token) {
+		return &principal{Bot: true}, 0, ""
+	}
+
+	if users == nil {
+		return nil, http.StatusServiceUnavailable, "database is not configured"
+	}
+
+	if auth.IsAPIToken(token) {
+		user, err := users.GetUserByAPIToken(ctx, auth.HashAPIToken(token))
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, http.StatusUnauthorized, "invalid API token"
+		}
+		if err != nil {
+			logError(ctx, "error resolving API token", err)
+			return nil, http.StatusInternalServerError, "failed to authenticate"
+		}
+		return &principal{User: user}, 0, ""
+	}
+
+	if jwtVerifier == nil {
+		return nil, http.StatusUnauthorized, "session tokens are not accepted"
+	}
+	claims, err := jwtVerifier.Verify(token, time.Now())
+	if err != nil {
+		return nil, http.StatusUnauthorized, "invalid or expired session token"
+	}
+	if claims.Email == "" {
+		return nil, http.StatusUnauthorized, "session token has no email"
+	}
+
+	user, err := users.LinkAuthUser(ctx, claims.Subject, claims.Email, claims.DisplayName())
+	if errors.Is(err, database.ErrConflict) {
+		return nil, http.StatusForbidden, "email is linked to a different account"
+	}
+	if err != nil {
+		logError(ctx, "error resolving session user", err)
+		return nil, http.StatusInternalServerError, "failed to authenticate"
+	}
+	return &principal{User: user}, 0, ""
+}
+
+// isBotToken reports whether token is BOT_API_TOKEN
+func isBotToken(token string) bool {
+	hash := auth.HashAPIToken(token)
+	return botTokenHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(botTokenHash)) == 1
+}
+
+// requireBot only lets the Discord bot through, for maintenance routes
+func requireBot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := currentPrincipal(r); p == nil || !p.Bot {
+			writeError(w, http.StatusForbidden, "this route is restricted to the bot token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireLeagueMember only lets members of the request's league, and the
+// bot, through. It must run after leagueFromRoute or defaultLeague.
+func requireLeagueMember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := currentPrincipal(r)
+		if p == nil || (!p.Bot && p.User == nil) {
+			writeUnauthorized(w, "missing bearer token")
+			return
+		}
+
+		member, err := canReadLeague(r, requestLeagueID(r))
+		if err != nil {
+			logError(r.Context(), "error checking league membership", err)
+			writeError(w, http.StatusInternalServerError, "failed to check league membership")
+			return
+		}
+		if !member {
+			writeError(w, http.StatusForbidden, "you are not a member of this league")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canReadLeague reports whether the request's principal may see leagueID:
+// the bot, or a user who is a member of it
+func canReadLeague(r *http.Request, leagueID string) (bool, error) {
+	p := currentPrincipal(r)
+	switch {
+	case p == nil:
+		return false, nil
+	case p.Bot:
+		return true, nil
+	case p.User == nil || users == nil:
+		return false, nil
+	}
+	return users.IsLeagueMember(r.Context(), leagueID, p.User.ID)
+}
+
+// handleGetMe returns the signed-in user
+func handleGetMe(w http.ResponseWriter, r *http.Request) {
+	p := currentPrincipal(r)
+	if p.User == nil {
+		writeError(w, http.StatusNotFound, "the bot token has no user")
+		return
+	}
+	writeJSON(w, http.StatusOK, p.User)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func writeUnauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="swishradar"`)
+	writeError(w, http.StatusUnauthorized, msg)
+}
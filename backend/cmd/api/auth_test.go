@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/auth"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// fakeUsers stands in for the database in authentication tests
+type fakeUsers struct {
+	tokens  map[string]*models.User // by token hash
+	emails  map[string]string       // email to the auth ID it is linked to
+	members map[string][]int        // league ID to user IDs
+	err     error                   // returned by every call when set
+}
+
+func (f *fakeUsers) GetUserByAPIToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if u, ok := f.tokens[tokenHash]; ok {
+		return u, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (f *fakeUsers) LinkAuthUser(ctx context.Context, authID, email, displayName string) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if linked, ok := f.emails[email]; ok && linked != authID {
+		return nil, database.ErrConflict
+	}
+	return &models.User{ID: 10, Email: email, DisplayName: displayName}, nil
+}
+
+func (f *fakeUsers) IsLeagueMember(ctx context.Context, leagueID string, userID int) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	for _, id := range f.members[leagueID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// withAuth sets the authentication globals for one test
+func withAuth(t *testing.T, store userStore) {
+	t.Helper()
+	oldUsers, oldVerifier, oldBot := users, jwtVerifier, botTokenHash
+	t.Cleanup(func() { users, jwtVerifier, botTokenHash = oldUsers, oldVerifier, oldBot })
+
+	users = store
+	jwtVerifier = auth.NewVerifier("jwt-secret", "authenticated")
+	botTokenHash = auth.HashAPIToken("bot-token")
+}
+
+// sessionToken signs a Supabase-style session JWT for the test secret
+func sessionToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte("jwt-secret"))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestRequireAuth(t *testing.T) {
+	alice := &models.User{ID: 1, Email: "alice@example.com"}
+	store := &fakeUsers{
+		tokens: map[string]*models.User{auth.HashAPIToken("sr_alice"): alice},
+		emails: map[string]string{"alice@example.com": "auth-alice"},
+	}
+	session := func(sub, email string) string {
+		return sessionToken(t, map[string]interface{}{
+			"sub":   sub,
+			"email": email,
+			"aud":   "authenticated",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	expired := sessionToken(t, map[string]interface{}{
+		"sub":   "auth-alice",
+		"email": "alice@example.com",
+		"aud":   "authenticated",
+		"exp":   time.Now().Add(-time.Hour).Unix(),
+	})
+
+	tests := []struct {
+		name          string
+		store         userStore
+		authorization string
+		wantStatus    int
+		wantUser      int // ID of the signed-in user, 0 for none
+		wantBot       bool
+	}{
+		{"no header", store, "", http.StatusUnauthorized, 0, false},
+		{"not bearer", store, "Basic c3I6YWxpY2U=", http.StatusUnauthorized, 0, false},
+		{"empty bearer", store, "Bearer ", http.StatusUnauthorized, 0, false},
+		{"bot token", store, "Bearer bot-token", http.StatusOK, 0, true},
+		{"bot token without a database", nil, "Bearer bot-token", http.StatusOK, 0, true},
+		{"API token", store, "Bearer sr_alice", http.StatusOK, 1, false},
+		{"lowercase scheme", store, "bearer sr_alice", http.StatusOK, 1, false},
+		{"unknown API token", store, "Bearer sr_nobody", http.StatusUnauthorized, 0, false},
+		{"API token lookup fails", &fakeUsers{err: errors.New("db down")}, "Bearer sr_alice", http.StatusInternalServerError, 0, false},
+		{"no database", nil, "Bearer sr_alice", http.StatusServiceUnavailable, 0, false},
+		{"session token", store, "Bearer " + session("auth-alice", "alice@example.com"), http.StatusOK, 10, false},
+		{"new session user", store, "Bearer " + session("auth-bob", "bob@example.com"), http.StatusOK, 10, false},
+		{"expired session token", store, "Bearer " + expired, http.StatusUnauthorized, 0, false},
+		{"garbage token", store, "Bearer not-a-jwt", http.StatusUnauthorized, 0, false},
+		{"session token without email", store, "Bearer " + session("auth-alice", ""), http.StatusUnauthorized, 0, false},
+		{"email linked to another account", store, "Bearer " + session("auth-mallory", "alice@example.com"), http.StatusForbidden, 0, false},
+		{"session user lookup fails", &fakeUsers{err: errors.New("db down")}, "Bearer " + session("auth-alice", "alice@example.com"), http.StatusInternalServerError, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withAuth(t, tt.store)
+
+			var got *principal
+			h := requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = currentPrincipal(r)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if (tt.wantStatus == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q on a %d", challenge, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if got != nil {
+					t.Error("rejected request reached the handler")
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("no principal on the request")
+			}
+			if got.Bot != tt.wantBot {
+				t.Errorf("Bot = %v, want %v", got.Bot, tt.wantBot)
+			}
+			id := 0
+			if got.User != nil {
+				id = got.User.ID
+			}
+			if id != tt.wantUser {
+				t.Errorf("user = %d, want %d", id, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequireLeagueMember(t *testing.T) {
+	store := &fakeUsers{members: map[string][]int{"1001": {1}}}
+	alice := &principal{User: &models.User{ID: 1}}
+	bob := &principal{User: &models.User{ID: 2}}
+	bot := &principal{Bot: true}
+
+	tests := []struct {
+		name       string
+		store      userStore
+		who        *principal
+		league     string
+		wantStatus int
+	}{
+		{"member", store, alice, "1001", http.StatusOK},
+		{"not a member", store, bob, "1001", http.StatusForbidden},
+		{"member of another league", store, alice, "2002", http.StatusForbidden},
+		{"bot", store, bot, "2002", http.StatusOK},
+		{"signed out", store, nil, "1001", http.StatusUnauthorized},
+		{"empty principal", store, &principal{}, "1001", http.StatusUnauthorized},
+		{"no database", nil, alice, "1001", http.StatusForbidden},
+		{"lookup fails", &fakeUsers{err: errors.New("db down")}, alice, "1001", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withAuth(t, tt.store)
+
+			reached := false
+			h := requireLeagueMember(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/leagues/"+tt.league+"/teams", nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, as(withLeague(r, tt.league), tt.who))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v on a %d", reached, w.Code)
+			}
+		})
+	}
+}
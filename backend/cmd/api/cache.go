@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/cache"
+	"github.com/milindkumar1/swishradar/internal/espn"
+)
+
+// Cache policies for ESPN-backed data. Stale entries are served for one more
+// TTL while they refresh in the background.
+var (
+	leagueCachePolicy     = cache.Policy{TTL: time.Minute, StaleWhileRevalidate: time.Minute}
+	teamsCachePolicy      = cache.Policy{TTL: time.Minute, StaleWhileRevalidate: time.Minute}
+	standingsCachePolicy  = cache.Policy{TTL: 5 * time.Minute, StaleWhileRevalidate: 5 * time.Minute}
+	freeAgentsCachePolicy = cache.Policy{TTL: 10 * time.Minute, StaleWhileRevalidate: 10 * time.Minute}
+)
+
+// memoryCacheMaxEntries bounds the in-memory store
+const memoryCacheMaxEntries = 1000
+
+// responseCache holds ESPN responses for the proxy routes and the native client
+var responseCache *cache.Cache
+
+// newResponseCache picks the cache store for CACHE_BACKEND ("memory" or
+// "postgres"), falling back to memory when the database is unavailable
+func newResponseCache(backend string) *cache.Cache {
+	if backend == "postgres" {
+		if db != nil {
+			return cache.New(cache.NewPostgresStore(db.DB))
+		}
+		slog.Warn("CACHE_BACKEND=postgres but the database is unavailable, using memory cache")
+	}
+	return cache.New(cache.NewMemoryStore(memoryCacheMaxEntries))
+}
+
+// cachedLeague fetches a league through the response cache. ESPN
+// rejecting the league's cookies is handled here, including on background
+// refreshes that no caller sees.
+func cachedLeague(ctx context.Context, client *espn.Client) (*espn.League, error) {
+	return cache.Fetch(ctx, responseCache, "espn:league:"+client.LeagueID, leagueCachePolicy, func(ctx context.Context) (*espn.League, error) {
+		league, err := client.GetLeague(ctx)
+		handleESPNRejection(ctx, client.LeagueID, err)
+		return league, err
+	})
+}
+
+// handlePurgeCache drops cached responses. An optional prefix query
+// parameter limits the purge, e.g. ?prefix=/api/espn/standings.
+func handlePurgeCache(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	n, err := responseCache.Purge(r.Context(), prefix)
+	if err != nil {
+		logError(r.Context(), "error purging cache", err)
+		writeError(w, http.StatusInternalServerError, "failed to purge cache")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"purged": n, "prefix": prefix})
+}
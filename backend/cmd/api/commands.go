@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/auth"
+	"github.com/milindkumar1/swishradar/internal/database"
+)
+
+// runCommand runs a one-off maintenance command instead of the server
+func runCommand(cfg apiConfig, args []string) {
+	switch args[0] {
+	case "migrate":
+		runMigrate(cfg, args[1:])
+	case "reencrypt":
+		runReencrypt(cfg)
+	case "token":
+		runToken(cfg, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], commandUsage)
+		os.Exit(2)
+	}
+}
+
+const commandUsage = `Commands:
+  migrate up                   apply every pending migration
+  migrate down                 revert the newest applied migration
+  migrate to <version>         migrate up or down to a version
+  migrate status               list migrations and when they were applied
+  reencrypt                    re-encrypt stored ESPN cookies with the primary credential key
+  token create <userID> <name> create an API token for a user
+  token revoke <tokenID>       revoke an API token
+`
+
+// runMigrate applies, reverts or lists schema migrations
+func runMigrate(cfg apiConfig, args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+
+	conn, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Database unavailable: %v", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+
+	var done []database.Migration
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		done, err = conn.MigrateUp(ctx)
+	case args[0] == "down" && len(args) == 1:
+		done, err = conn.MigrateDown(ctx)
+	case args[0] == "to" && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			log.Fatalf("Invalid version %q", args[1])
+		}
+		done, err = conn.MigrateTo(ctx, version)
+	case args[0] == "status" && len(args) == 1:
+		printMigrationStatus(ctx, conn)
+		return
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+
+	for _, m := range done {
+		fmt.Printf("Ran %03d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if len(done) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+}
+
+func printMigrationStatus(ctx context.Context, conn *database.DB) {
+	states, err := conn.MigrationStatus(ctx)
+	if err != nil {
+		log.Fatalf("Error reading migration status: %v", err)
+	}
+	for _, s := range states {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = "applied " + s.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("%03d_%-32s %s\n", s.Version, s.Name, applied)
+	}
+}
+
+// runToken creates or revokes API tokens. A created token is printed once
+// and cannot be recovered later.
+func runToken(cfg apiConfig, args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+
+	conn, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Database unavailable: %v", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+
+	switch {
+	case args[0] == "create" && len(args) == 3:
+		userID, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatalf("Invalid user id %q", args[1])
+		}
+		token, hash, err := auth.NewAPIToken()
+		if err != nil {
+			log.Fatal(err)
+		}
+		id, err := conn.CreateAPIToken(ctx, userID, args[2], hash)
+		if errors.Is(err, database.ErrNotFound) {
+			log.Fatalf("User %d not found", userID)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Created API token %d for user %d:\n%s\n", id, userID, token)
+	case args[0] == "revoke" && len(args) == 2:
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatalf("Invalid token id %q", args[1])
+		}
+		if err := conn.RevokeAPIToken(ctx, id); errors.Is(err, database.ErrNotFound) {
+			log.Fatalf("Token %d not found or already revoked", id)
+		} else if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Revoked API token %d\n", id)
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+}
+
+// runReencrypt moves every stored ESPN cookie onto the primary credential key,
+// encrypting any that are still plaintext
+func runReencrypt(cfg apiConfig) {
+	conn, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Database unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	n, err := conn.ReencryptCredentials(ctx)
+	if err != nil {
+		log.Fatalf("Error re-encrypting credentials after %d users: %v", n, err)
+	}
+	fmt.Printf("Re-encrypted credentials for %d users\n", n)
+}
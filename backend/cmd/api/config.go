@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/logging"
+)
+
+// apiConfig is every setting the API reads, loaded with config.Load from
+// the environment, .env and CONFIG_FILE
+type apiConfig struct {
+	Port        string   `env:"PORT" default:"8081"`
+	CORSOrigins []string `env:"CORS_ORIGINS" default:"http://localhost:3000,http://localhost:3001"`
+
+	// Server timeouts. WriteTimeout has to cover the slowest upstream route,
+	// free agents with its retries. On SIGTERM in-flight requests and
+	// background jobs get ShutdownTimeout to finish.
+	ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"30s"`
+	WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"60s"`
+	IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"120s"`
+	ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	// ESPNSource is "service" to proxy /api/espn/* to the Python
+	// espn-service at ESPNServiceURL, or "native" to serve them from the
+	// ESPN client pool
+	ESPNSource     string `env:"ESPN_SOURCE" default:"service"`
+	ESPNServiceURL string `env:"ESPN_SERVICE_URL" default:"http://localhost:5001"`
+
+	// ESPNLeagueID is the default league for unscoped routes, read with the
+	// cookies below rather than a member's
+	ESPNLeagueID string `env:"ESPN_LEAGUE_ID"`
+	ESPNSWID     string `env:"ESPN_SWID" secret:"true"`
+	ESPNS2       string `env:"ESPN_S2" secret:"true"`
+
+	// Outbound budgets shared by every client calling the host. Throttled
+	// and failing GETs are retried with backoff within the same budget. A
+	// rate of 0 turns pacing off.
+	ESPNRequestsPerMinute int `env:"ESPN_REQUESTS_PER_MINUTE" default:"60"`
+	ESPNBurst             int `env:"ESPN_BURST" default:"10"`
+	NBARequestsPerMinute  int `env:"NBA_REQUESTS_PER_MINUTE" default:"30"`
+	NBABurst              int `env:"NBA_BURST" default:"5"`
+
+	// Inbound requests a minute per user on every /api route and, on top
+	// of that, on the analytics routes. The bot token gets BotRateLimit on
+	// each instead. 0 turns a limit off.
+	RateLimit          int `env:"RATE_LIMIT_PER_MINUTE" default:"120"`
+	AnalyticsRateLimit int `env:"RATE_LIMIT_ANALYTICS_PER_MINUTE" default:"20"`
+	BotRateLimit       int `env:"RATE_LIMIT_BOT_PER_MINUTE" default:"1200"`
+	// IPRateLimit applies per client IP before the token is checked, so
+	// tokens can't be guessed at any rate. The bot token skips it.
+	IPRateLimit int `env:"RATE_LIMIT_IP_PER_MINUTE" default:"300"`
+
+	// TrustedProxyHops is how many proxies in front of the API append to
+	// X-Forwarded-For. Client IPs are read from the entry the outermost one
+	// added; with 0 the header is ignored, since clients can write anything
+	// in it.
+	TrustedProxyHops int `env:"TRUSTED_PROXY_HOPS" default:"0"`
+
+	CacheBackend            string        `env:"CACHE_BACKEND" default:"memory"`
+	LeagueSyncInterval      time.Duration `env:"LEAGUE_SYNC_INTERVAL" default:"30m"`
+	TransactionPollInterval time.Duration `env:"TRANSACTION_POLL_INTERVAL" default:"5m"`
+	MigrateOnStart          bool          `env:"MIGRATE_ON_START"`
+
+	// LogFormat is "json" or "text"; LogLevel is debug, info, warn or error.
+	// Upstream calls that succeed are only logged at debug.
+	LogFormat string `env:"LOG_FORMAT" default:"json"`
+	LogLevel  string `env:"LOG_LEVEL" default:"info"`
+
+	JWTSecret         string `env:"SUPABASE_JWT_SECRET" secret:"true"`
+	BotAPIToken       string `env:"BOT_API_TOKEN" secret:"true"`
+	DiscordWebhookURL string `env:"DISCORD_WEBHOOK_URL" secret:"true"`
+
+	Database database.Config
+}
+
+// Validate checks the settings the API can't start with
+func (c *apiConfig) Validate() error {
+	var errs []error
+	if n, err := strconv.Atoi(c.Port); err != nil || n <= 0 || n > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", c.Port))
+	}
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ORIGINS must list at least one origin"))
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("CORS_ORIGINS: %q is not an origin such as https://swishradar.com", origin))
+		}
+	}
+
+	for _, t := range []struct {
+		key string
+		d   time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+	} {
+		if t.d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", t.key))
+		}
+	}
+
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", c.LogFormat))
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %v", err))
+	}
+
+	switch c.ESPNSource {
+	case "service":
+		if u, err := url.Parse(c.ESPNServiceURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("ESPN_SERVICE_URL must be an absolute URL, got %q", c.ESPNServiceURL))
+		}
+	case "native":
+	default:
+		errs = append(errs, fmt.Errorf("ESPN_SOURCE must be native or service, got %q", c.ESPNSource))
+	}
+	if (c.ESPNSWID == "") != (c.ESPNS2 == "") {
+		errs = append(errs, errors.New("ESPN_SWID and ESPN_S2 must be set together"))
+	}
+
+	if c.ESPNRequestsPerMinute < 0 {
+		errs = append(errs, errors.New("ESPN_REQUESTS_PER_MINUTE must not be negative"))
+	}
+	if c.NBARequestsPerMinute < 0 {
+		errs = append(errs, errors.New("NBA_REQUESTS_PER_MINUTE must not be negative"))
+	}
+	if c.ESPNBurst < 1 {
+		errs = append(errs, errors.New("ESPN_BURST must be at least 1"))
+	}
+	if c.NBABurst < 1 {
+		errs = append(errs, errors.New("NBA_BURST must be at least 1"))
+	}
+
+	if c.RateLimit < 0 || c.AnalyticsRateLimit < 0 || c.BotRateLimit < 0 || c.IPRateLimit < 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_* settings must not be negative"))
+	}
+	if c.TrustedProxyHops < 0 {
+		errs = append(errs, errors.New("TRUSTED_PROXY_HOPS must not be negative"))
+	}
+
+	switch c.CacheBackend {
+	case "memory", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("CACHE_BACKEND must be memory or postgres, got %q", c.CacheBackend))
+	}
+	if c.LeagueSyncInterval <= 0 {
+		errs = append(errs, errors.New("LEAGUE_SYNC_INTERVAL must be positive"))
+	}
+	if c.TransactionPollInterval <= 0 {
+		errs = append(errs, errors.New("TRANSACTION_POLL_INTERVAL must be positive"))
+	}
+
+	return errors.Join(append(errs, c.Database.Validate())...)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// swidPattern matches an ESPN SWID cookie, a GUID in braces
+var swidPattern = regexp.MustCompile(`^\{[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\}$`)
+
+// espnCredentialsRequest is the PUT /api/v1/me/espn-credentials body. The
+// cookies are checked by reading league_id, which defaults to one of the
+// user's leagues. When league_id is given the user also joins that league.
+type espnCredentialsRequest struct {
+	SWID     string `json:"swid"`
+	S2       string `json:"espn_s2"`
+	LeagueID string `json:"league_id"`
+}
+
+// espnCredentialsStatus describes the signed-in user's ESPN cookies
+type espnCredentialsStatus struct {
+	Linked    bool       `json:"linked"`
+	Status    string     `json:"status,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Leagues   []string   `json:"leagues"`
+}
+
+func handleGetESPNCredentials(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	writeCredentialsStatus(w, r, user.ID)
+}
+
+func handlePutESPNCredentials(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req espnCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.SWID = strings.TrimSpace(req.SWID)
+	req.S2 = strings.TrimSpace(req.S2)
+	if req.SWID != "" && !strings.HasPrefix(req.SWID, "{") {
+		req.SWID = "{" + req.SWID + "}"
+	}
+	if !swidPattern.MatchString(req.SWID) {
+		writeError(w, http.StatusBadRequest, "swid must be the SWID cookie, a GUID such as {XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX}")
+		return
+	}
+	if req.S2 == "" {
+		writeError(w, http.StatusBadRequest, "espn_s2 is required")
+		return
+	}
+
+	leagues, err := db.ListUserLeagues(r.Context(), user.ID)
+	if err != nil {
+		logError(r.Context(), "error listing leagues", err, "user_id", user.ID)
+		writeError(w, http.StatusInternalServerError, "failed to load leagues")
+		return
+	}
+	leagueID := req.LeagueID
+	if leagueID == "" && len(leagues) > 0 {
+		leagueID = leagues[0]
+	}
+	if leagueID == "" {
+		leagueID = defaultLeagueID
+	}
+	if leagueID == "" {
+		writeError(w, http.StatusBadRequest, "league_id is required to check the credentials")
+		return
+	}
+
+	// A cheap read proves the cookies work before they replace old ones
+	client := espn.NewClient(leagueID, espn.CurrentSeason(time.Now()), req.SWID, req.S2)
+	if _, err := client.GetLeague(r.Context()); errors.Is(err, espn.ErrUnauthorized) {
+		writeError(w, http.StatusUnprocessableEntity, "ESPN rejected these credentials for league "+leagueID+"; copy fresh SWID and espn_s2 cookies")
+		return
+	} else if err != nil {
+		logError(r.Context(), "error checking ESPN credentials", err, "user_id", user.ID)
+		writeError(w, http.StatusBadGateway, "failed to reach ESPN to check the credentials")
+		return
+	}
+
+	err = db.SetUserESPNCredentials(r.Context(), user.ID, req.SWID, req.S2)
+	if errors.Is(err, database.ErrNoCredentialKey) {
+		writeError(w, http.StatusServiceUnavailable, "credential encryption is not configured")
+		return
+	}
+	if err != nil {
+		logError(r.Context(), "error storing ESPN credentials", err, "user_id", user.ID)
+		writeError(w, http.StatusInternalServerError, "failed to store credentials")
+		return
+	}
+
+	if req.LeagueID != "" {
+		if err := db.AddLeagueMember(r.Context(), req.LeagueID, user.ID, espn.CurrentSeason(time.Now())); err != nil {
+			logError(r.Context(), "error adding league member", err, "user_id", user.ID, "league_id", req.LeagueID)
+			writeError(w, http.StatusInternalServerError, "failed to join league")
+			return
+		}
+		leagues = append(leagues, req.LeagueID)
+	}
+	invalidateLeagues(leagues)
+
+	writeCredentialsStatus(w, r, user.ID)
+}
+
+func handleDeleteESPNCredentials(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := db.ClearUserESPNCredentials(r.Context(), user.ID); err != nil {
+		logError(r.Context(), "error clearing ESPN credentials", err, "user_id", user.ID)
+		writeError(w, http.StatusInternalServerError, "failed to clear credentials")
+		return
+	}
+
+	leagues, err := db.ListUserLeagues(r.Context(), user.ID)
+	if err != nil {
+		logError(r.Context(), "error listing leagues", err, "user_id", user.ID)
+	}
+	invalidateLeagues(leagues)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentUser returns the signed-in user, rejecting the bot token which has
+// no user of its own
+func currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	p := currentPrincipal(r)
+	if p == nil || p.User == nil {
+		writeError(w, http.StatusForbidden, "this route needs a user token")
+		return nil, false
+	}
+	return p.User, true
+}
+
+func writeCredentialsStatus(w http.ResponseWriter, r *http.Request, userID int) {
+	user, err := db.GetUser(r.Context(), userID)
+	if err != nil {
+		logError(r.Context(), "error loading user", err, "user_id", userID)
+		writeError(w, http.StatusInternalServerError, "failed to load credentials")
+		return
+	}
+	leagues, err := db.ListUserLeagues(r.Context(), userID)
+	if err != nil {
+		logError(r.Context(), "error listing leagues", err, "user_id", userID)
+		writeError(w, http.StatusInternalServerError, "failed to load leagues")
+		return
+	}
+
+	status := espnCredentialsStatus{
+		Linked:    user.ESPNSWID != nil && user.ESPNS2 != nil,
+		CheckedAt: user.ESPNCheckedAt,
+		Leagues:   leagues,
+	}
+	if status.Leagues == nil {
+		status.Leagues = []string{}
+	}
+	switch {
+	case user.ESPNStatus != nil:
+		status.Status = *user.ESPNStatus
+	case status.Linked:
+		// Stored before statuses were tracked
+		status.Status = "unknown"
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// invalidateLeagues drops pooled clients so changed cookies are used on the
+// next request
+func invalidateLeagues(leagueIDs []string) {
+	for _, id := range leagueIDs {
+		espnPool.Invalidate(id)
+	}
+}
+
+// handleESPNRejection reacts to ESPN rejecting a league's cookies: the
+// member whose cookies were used is marked expired and told to refresh
+// them, and the league's client is dropped so another member's cookies are
+// tried next time. Every call made with a pooled league client passes its
+// error here, once; it does nothing for other errors.
+func handleESPNRejection(ctx context.Context, leagueID string, err error) {
+	if !errors.Is(err, espn.ErrUnauthorized) || db == nil {
+		return
+	}
+	if leagueID == defaultLeagueID {
+		slog.WarnContext(ctx, "ESPN rejected ESPN_SWID/ESPN_S2; refresh them in the environment", "league_id", leagueID)
+		return
+	}
+
+	userID, _, _, err := db.GetLeagueCredentials(ctx, leagueID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			logError(ctx, "error finding credentials ESPN rejected", err, "league_id", leagueID)
+		}
+		return
+	}
+	changed, err := db.MarkESPNCredentialsExpired(ctx, userID)
+	if err != nil {
+		logError(ctx, "error marking ESPN credentials expired", err, "user_id", userID)
+		return
+	}
+	espnPool.Invalidate(leagueID)
+	if !changed {
+		return
+	}
+
+	name := fmt.Sprintf("User %d", userID)
+	if user, err := db.GetUser(ctx, userID); err == nil {
+		name = user.DisplayName
+	}
+	notifyDiscord(ctx, fmt.Sprintf(
+		"⚠️ ESPN stopped accepting %s's cookies for league %s. Copy fresh SWID and espn_s2 cookies into PUT /api/v1/me/espn-credentials.",
+		name, leagueID))
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/espn"
+)
+
+// The native ESPN handlers serve a league's data straight from ESPN,
+// returning the same JSON as the Python espn-service so the frontend works
+// against either. They back /api/v1/leagues/{leagueID}/espn/* and, when
+// ESPN_SOURCE=native, /api/espn/* for the default league.
+
+type espnLeagueInfo struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Year        int    `json:"year"`
+	Size        int    `json:"size"`
+	CurrentWeek int    `json:"current_week"`
+}
+
+type espnOwner struct {
+	DisplayName string `json:"displayName"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	ID          string `json:"id"`
+}
+
+type espnRosterPlayer struct {
+	Name     string `json:"name"`
+	Position string `json:"position"`
+	ProTeam  string `json:"proTeam"`
+	Injured  bool   `json:"injured"`
+}
+
+type espnTeam struct {
+	ID     int                `json:"id"`
+	Name   string             `json:"name"`
+	Owners []espnOwner        `json:"owners"`
+	Wins   int                `json:"wins"`
+	Losses int                `json:"losses"`
+	Roster []espnRosterPlayer `json:"roster"`
+}
+
+type espnFreeAgent struct {
+	Name        string  `json:"name"`
+	Position    string  `json:"position"`
+	ProTeam     string  `json:"proTeam"`
+	AvgPoints   float64 `json:"avg_points"`
+	TotalPoints float64 `json:"total_points"`
+}
+
+type espnStanding struct {
+	Rank          int         `json:"rank"`
+	TeamName      string      `json:"team_name"`
+	Owners        []espnOwner `json:"owners"`
+	Wins          int         `json:"wins"`
+	Losses        int         `json:"losses"`
+	PointsFor     float64     `json:"points_for"`
+	PointsAgainst float64     `json:"points_against"`
+}
+
+// handleNativeESPNHealth mirrors the service's /health, reporting whether
+// the league can be fetched
+func handleNativeESPNHealth(w http.ResponseWriter, r *http.Request) {
+	connected := false
+	if client, err := leagueClient(r); err == nil {
+		if _, err := cachedLeague(r.Context(), client); err != nil {
+			logError(r.Context(), "error checking ESPN connection", err)
+		} else {
+			connected = true
+		}
+	}
+
+	status := "disconnected"
+	if connected {
+		status = "healthy"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": status, "connected": connected})
+}
+
+func handleNativeLeague(w http.ResponseWriter, r *http.Request) {
+	league, ok := loadNativeLeague(w, r)
+	if !ok {
+		return
+	}
+
+	year := league.Season
+	if year == 0 {
+		year = espn.CurrentSeason(time.Now())
+	}
+	writeJSON(w, http.StatusOK, espnLeagueInfo{
+		ID:          league.ID,
+		Name:        league.Settings.Name,
+		Year:        year,
+		Size:        len(league.Teams),
+		CurrentWeek: league.Status.CurrentMatchupPeriod,
+	})
+}
+
+func handleNativeTeams(w http.ResponseWriter, r *http.Request) {
+	league, ok := loadNativeLeague(w, r)
+	if !ok {
+		return
+	}
+
+	teams := make([]espnTeam, 0, len(league.Teams))
+	for _, team := range league.Teams {
+		roster := make([]espnRosterPlayer, 0, len(team.Roster.Entries))
+		for _, entry := range team.Roster.Entries {
+			p := entry.PlayerPoolEntry.Player
+			roster = append(roster, espnRosterPlayer{
+				Name:     p.FullName,
+				Position: p.Position(),
+				ProTeam:  p.ProTeam(),
+				Injured:  p.Injured,
+			})
+		}
+
+		teams = append(teams, espnTeam{
+			ID:     team.ID,
+			Name:   team.DisplayName(),
+			Owners: teamOwners(league, team),
+			Wins:   team.Record.Overall.Wins,
+			Losses: team.Record.Overall.Losses,
+			Roster: roster,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"teams": teams})
+}
+
+// handleNativeStandings ranks teams by overall win percentage, breaking ties
+// on points for
+func handleNativeStandings(w http.ResponseWriter, r *http.Request) {
+	league, ok := loadNativeLeague(w, r)
+	if !ok {
+		return
+	}
+
+	teams := append([]espn.Team(nil), league.Teams...)
+	sort.SliceStable(teams, func(i, j int) bool {
+		a, b := teams[i].Record.Overall, teams[j].Record.Overall
+		pctA, pctB := winPct(a.Wins, a.Losses, a.Ties), winPct(b.Wins, b.Losses, b.Ties)
+		if pctA != pctB {
+			return pctA > pctB
+		}
+		return a.PointsFor > b.PointsFor
+	})
+
+	standings := make([]espnStanding, 0, len(teams))
+	for i, team := range teams {
+		record := team.Record.Overall
+		standings = append(standings, espnStanding{
+			Rank:          i + 1,
+			TeamName:      team.DisplayName(),
+			Owners:        teamOwners(league, team),
+			Wins:          record.Wins,
+			Losses:        record.Losses,
+			PointsFor:     record.PointsFor,
+			PointsAgainst: record.PointsAgainst,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"standings": standings})
+}
+
+// handleNativeFreeAgents lists free agents and waiver players, optionally
+// filtered by ?position= and ordered by ?sort=owned (default) or last7
+func handleNativeFreeAgents(w http.ResponseWriter, r *http.Request) {
+	client, ok := loadLeagueClient(w, r)
+	if !ok {
+		return
+	}
+
+	q := espn.FreeAgentQuery{
+		Limit:  queryInt(r, "limit", 50),
+		Offset: queryInt(r, "offset", 0),
+	}
+	if position := r.URL.Query().Get("position"); position != "" {
+		slot, ok := espn.SlotForPosition(position)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown position "+position)
+			return
+		}
+		q.Slots = []int{slot}
+	}
+	switch r.URL.Query().Get("sort") {
+	case "", "owned":
+	case "last7":
+		q.SortBy = espn.SortLast7Points
+	default:
+		writeError(w, http.StatusBadRequest, "sort must be owned or last7")
+		return
+	}
+
+	pool, err := client.GetFreeAgents(r.Context(), q)
+	if err != nil {
+		handleESPNRejection(r.Context(), client.LeagueID, err)
+		logError(r.Context(), "error fetching free agents", err)
+		writeError(w, http.StatusBadGateway, "failed to fetch free agents from ESPN")
+		return
+	}
+
+	agents := make([]espnFreeAgent, 0, len(pool))
+	for _, entry := range pool {
+		p := entry.Player
+		agent := espnFreeAgent{Name: p.FullName, Position: p.Position(), ProTeam: p.ProTeam()}
+		if season, ok := p.Split(espn.SplitSeason); ok {
+			agent.AvgPoints = season.AppliedAverage
+			agent.TotalPoints = season.AppliedTotal
+		}
+		agents = append(agents, agent)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"players": agents})
+}
+
+// loadNativeLeague fetches the request's league through the cache, writing an
+// error response and returning false on failure
+func loadNativeLeague(w http.ResponseWriter, r *http.Request) (*espn.League, bool) {
+	client, ok := loadLeagueClient(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	league, err := cachedLeague(r.Context(), client)
+	if err != nil {
+		logError(r.Context(), "error fetching league", err)
+		writeError(w, http.StatusBadGateway, "failed to fetch league from ESPN")
+		return nil, false
+	}
+	return league, true
+}
+
+func teamOwners(league *espn.League, team espn.Team) []espnOwner {
+	members := league.TeamOwners(team)
+	owners := make([]espnOwner, 0, len(members))
+	for _, m := range members {
+		owners = append(owners, espnOwner{DisplayName: m.DisplayName, FirstName: m.FirstName, LastName: m.LastName, ID: m.ID})
+	}
+	return owners
+}
+
+func winPct(wins, losses, ties int) float64 {
+	games := wins + losses + ties
+	if games == 0 {
+		return 0
+	}
+	return (float64(wins) + 0.5*float64(ties)) / float64(games)
+}
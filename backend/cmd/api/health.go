@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/logging"
+)
+
+// readinessCheck probes one dependency. It returns details worth showing
+// alongside the result, such as schema versions, and an error when the
+// dependency can't serve requests. A nil check means the dependency is not
+// configured.
+type readinessCheck func(ctx context.Context) (interface{}, error)
+
+// readinessChecks are run by /health/ready, keyed by dependency name. main
+// fills them in once it knows which dependencies are configured.
+var readinessChecks = map[string]readinessCheck{}
+
+// readinessTimeout bounds every check so a hung dependency fails the probe
+// instead of stalling it
+const readinessTimeout = 3 * time.Second
+
+// dependencyHealth is the outcome of one readiness check
+type dependencyHealth struct {
+	// Status is "ok", "down", or "disabled" when the dependency is not
+	// configured, which doesn't fail readiness
+	Status    string      `json:"status"`
+	LatencyMS float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// handleLive answers liveness probes. It touches no dependencies, so it only
+// fails when the process can't serve HTTP at all.
+func handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "alive",
+		"timestamp": time.Now(),
+	})
+}
+
+// handleReady runs every readiness check in parallel and answers 503 when
+// any configured dependency is down
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]dependencyHealth, len(readinessChecks))
+	)
+	for name, check := range readinessChecks {
+		if check == nil {
+			results[name] = dependencyHealth{Status: "disabled"}
+			continue
+		}
+		wg.Add(1)
+		go func(name string, check readinessCheck) {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check(ctx)
+			h := dependencyHealth{
+				Status:    "ok",
+				LatencyMS: logging.Milliseconds(time.Since(start)),
+				Details:   details,
+			}
+			if err != nil {
+				h.Status, h.Error = "down", err.Error()
+			}
+			mu.Lock()
+			results[name] = h
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status, code := "healthy", http.StatusOK
+	for _, h := range results {
+		if h.Status == "down" {
+			status, code = "unhealthy", http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, map[string]interface{}{
+		"status":       status,
+		"timestamp":    time.Now(),
+		"dependencies": results,
+	})
+}
+
+// checkDatabase pings the database and reports the pool stats
+func checkDatabase(ctx context.Context) (interface{}, error) {
+	h := db.HealthCheck(ctx)
+	if !h.OK {
+		return h.Pool, errors.New(h.Error)
+	}
+	return h.Pool, nil
+}
+
+// checkSchema fails while migrations this build expects are unapplied. A
+// newer schema is fine, as it is for CheckSchema at startup.
+func checkSchema(ctx context.Context) (interface{}, error) {
+	latest, err := database.LatestSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	current, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]int{"version": current, "expected": latest}
+	if current < latest {
+		return details, database.ErrSchemaOutdated
+	}
+	return details, nil
+}
+
+// checkNativeESPN fetches the default league with its pinned credentials.
+// The league comes from the response cache when it is fresh, so probes
+// don't add ESPN traffic.
+func checkNativeESPN(ctx context.Context) (interface{}, error) {
+	client, err := espnPool.Client(ctx, defaultLeagueID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cachedLeague(ctx, client); err != nil {
+		return nil, err
+	}
+	return map[string]string{"league_id": defaultLeagueID}, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/logging"
+)
+
+// espnPool holds an ESPN client per league. The league from ESPN_LEAGUE_ID,
+// if set, is pinned with the ESPN_SWID/ESPN_S2 cookies; other leagues use a
+// member's cookies from the users table.
+var espnPool *espn.Pool
+
+// defaultLeagueID is the league served by routes without a {leagueID}
+// parameter, such as /api/espn/*. It is empty when ESPN_LEAGUE_ID is unset.
+var defaultLeagueID string
+
+// errNoDefaultLeague is returned for unscoped routes when no default league
+// is configured, and for requests that aren't scoped to a league at all
+var errNoDefaultLeague = errors.New("ESPN league is not configured")
+
+// dbCredentials resolves league credentials from league members in the
+// database
+type dbCredentials struct{}
+
+func (dbCredentials) LeagueCredentials(ctx context.Context, leagueID string) (espn.Credentials, error) {
+	if db == nil {
+		return espn.Credentials{}, espn.ErrNoCredentials
+	}
+	_, swid, s2, err := db.GetLeagueCredentials(ctx, leagueID)
+	if errors.Is(err, database.ErrNotFound) {
+		return espn.Credentials{}, espn.ErrNoCredentials
+	}
+	if err != nil {
+		return espn.Credentials{}, err
+	}
+	return espn.Credentials{SWID: swid, S2: s2}, nil
+}
+
+type leagueIDKey struct{}
+
+// requestLeagueID returns the league a request is scoped to by
+// leagueFromRoute or defaultLeague, or "" on routes that are neither
+func requestLeagueID(r *http.Request) string {
+	id, _ := r.Context().Value(leagueIDKey{}).(string)
+	return id
+}
+
+// withLeague scopes r to leagueID. The ID is kept in a context value of its
+// own rather than read from chi's route context, which chi reuses once the
+// request finishes, so background cache refreshes see the same league.
+func withLeague(r *http.Request, leagueID string) *http.Request {
+	logging.Add(r.Context(), "league_id", leagueID)
+	return r.WithContext(context.WithValue(r.Context(), leagueIDKey{}, leagueID))
+}
+
+// leagueFromRoute scopes /leagues/{leagueID} routes to their parameter
+func leagueFromRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, withLeague(r, chi.URLParam(r, "leagueID")))
+	})
+}
+
+// defaultLeagueIfMember scopes routes where league data is optional, like
+// a player's ownership, to ESPN_LEAGUE_ID when the caller may read it, and
+// leaves them unscoped otherwise
+func defaultLeagueIfMember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if defaultLeagueID != "" {
+			member, err := canReadLeague(r, defaultLeagueID)
+			if err != nil {
+				logError(r.Context(), "error checking league membership", err)
+			} else if member {
+				r = withLeague(r, defaultLeagueID)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// defaultLeague scopes routes without a {leagueID}, such as /api/espn/*,
+// to ESPN_LEAGUE_ID, answering 503 when it isn't set
+func defaultLeague(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if defaultLeagueID == "" {
+			writeError(w, http.StatusServiceUnavailable, errNoDefaultLeague.Error())
+			return
+		}
+		next.ServeHTTP(w, withLeague(r, defaultLeagueID))
+	})
+}
+
+// leagueClient returns the ESPN client for the request's league
+func leagueClient(r *http.Request) (*espn.Client, error) {
+	leagueID := requestLeagueID(r)
+	if leagueID == "" {
+		return nil, errNoDefaultLeague
+	}
+	return espnPool.Client(r.Context(), leagueID)
+}
+
+// loadLeagueClient is leagueClient for handlers that need ESPN, writing an
+// error response and returning false when the league can't be read
+func loadLeagueClient(w http.ResponseWriter, r *http.Request) (*espn.Client, bool) {
+	client, err := leagueClient(r)
+	switch {
+	case errors.Is(err, errNoDefaultLeague):
+		writeError(w, http.StatusServiceUnavailable, "ESPN league is not configured")
+		return nil, false
+	case errors.Is(err, espn.ErrNoCredentials):
+		writeError(w, http.StatusNotFound, "no league member has working ESPN credentials")
+		return nil, false
+	case err != nil:
+		logError(r.Context(), "error resolving ESPN client", err, "league_id", requestLeagueID(r))
+		writeError(w, http.StatusInternalServerError, "failed to load league credentials")
+		return nil, false
+	}
+	return client, true
+}
+
+// activeLeagues returns the clients for every league the background workers
+// should keep in sync: the default league plus any league with a member who
+// has ESPN cookies
+func activeLeagues(ctx context.Context) ([]*espn.Client, error) {
+	ids, err := db.ListCredentialedLeagues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if defaultLeagueID != "" {
+		ids = append([]string{defaultLeagueID}, ids...)
+	}
+
+	seen := make(map[string]bool, len(ids))
+	clients := make([]*espn.Client, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		client, err := espnPool.Client(ctx, id)
+		if err != nil {
+			logError(ctx, "error resolving ESPN client", err, "league_id", id)
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// forEachLeague runs fn for every active league, continuing past failures
+func forEachLeague(ctx context.Context, name string, fn func(ctx context.Context, client *espn.Client) error) error {
+	clients, err := activeLeagues(ctx)
+	if err != nil {
+		return err
+	}
+	for _, client := range clients {
+		ctx := logging.With(ctx, "league_id", client.LeagueID)
+		if err := fn(ctx, client); err != nil {
+			logError(ctx, "error running "+name, err)
+		}
+	}
+	return nil
+}
+
+// handleAddLeagueMember links a user to a league so the league can be read
+// with their ESPN cookies. The bot can add anyone; users can only add
+// themselves, and only to leagues their ESPN account can read.
+func handleAddLeagueMember(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	leagueID := chi.URLParam(r, "leagueID")
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if p := currentPrincipal(r); !p.Bot {
+		if p.User.ID != userID {
+			writeError(w, http.StatusForbidden, "you can only add yourself to a league")
+			return
+		}
+		if p.User.ESPNSWID == nil || p.User.ESPNS2 == nil {
+			writeError(w, http.StatusBadRequest, "link your ESPN account before joining a league")
+			return
+		}
+		// Membership grants access to the league's data, so prove the
+		// user's own ESPN account can read it
+		client := espn.NewClient(leagueID, espn.CurrentSeason(time.Now()), *p.User.ESPNSWID, *p.User.ESPNS2)
+		if _, err := client.GetLeague(r.Context()); err != nil {
+			writeError(w, http.StatusForbidden, "your ESPN account cannot read this league")
+			return
+		}
+	}
+
+	err = db.AddLeagueMember(r.Context(), leagueID, userID, espn.CurrentSeason(time.Now()))
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		logError(r.Context(), "error adding league member", err)
+		writeError(w, http.StatusInternalServerError, "failed to add league member")
+		return
+	}
+
+	// Pick up the new member's cookies on the next request
+	espnPool.Invalidate(leagueID)
+	w.WriteHeader(http.StatusNoContent)
+}
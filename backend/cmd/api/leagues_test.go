@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/milindkumar1/swishradar/internal/cache"
+)
+
+// A stale entry for one league must be refreshed for that league, not for
+// ESPN_LEAGUE_ID, even though the refresh runs after chi has recycled the
+// request's route context
+func TestStaleRefreshKeepsLeague(t *testing.T) {
+	defer func(id string, c *cache.Cache) { defaultLeagueID, responseCache = id, c }(defaultLeagueID, responseCache)
+	defaultLeagueID = "default"
+	responseCache = cache.New(cache.NewMemoryStore(100))
+
+	var calls atomic.Int32
+	r := chi.NewRouter()
+	r.Route("/api/v1/leagues/{leagueID}", func(r chi.Router) {
+		r.Use(leagueFromRoute)
+		policy := cache.Policy{TTL: 100 * time.Millisecond, StaleWhileRevalidate: time.Minute}
+		r.Method(http.MethodGet, "/espn/standings", responseCache.Handler(policy, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %d", requestLeagueID(r), calls.Add(1))
+		})))
+	})
+	// Another league's requests reuse the pooled route context
+	r.Get("/api/v1/other/{leagueID}", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := get("/api/v1/leagues/X/espn/standings"); body != "X 1" {
+		t.Fatalf("first response = %q, want %q", body, "X 1")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if body := get("/api/v1/leagues/X/espn/standings"); body != "X 1" {
+		t.Fatalf("stale response = %q, want %q", body, "X 1")
+	}
+	get("/api/v1/other/Y")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for {
+		body := get("/api/v1/leagues/X/espn/standings")
+		if body != "X 1" {
+			if !strings.HasPrefix(body, "X ") {
+				t.Fatalf("refreshed response = %q, want league X", body)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("refreshed entry was not stored, last response %q", body)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/milindkumar1/swishradar/internal/logging"
+)
+
+// requestLogger writes one record per request with its route pattern,
+// status and latency, at error level for 5xx responses. Everything logged
+// with the request's context carries its request ID, which is also
+// returned in X-Request-Id. It must run after middleware.RequestID.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+
+		ctx := logging.With(r.Context(), "request_id", requestID)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routePattern(r),
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"latency_ms", logging.Milliseconds(time.Since(start)),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// routePattern returns the chi pattern that matched r, such as
+// /api/v1/players/{id}, once the router has run. It is empty for requests
+// no route matched.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// logError logs err with the fields ctx carries. When ctx belongs to a
+// request the error is also added to the request's own log record.
+func logError(ctx context.Context, msg string, err error, args ...any) {
+	slog.ErrorContext(ctx, msg, append(args, "error", err)...)
+	logging.Add(ctx, "error", err.Error())
+}
+
+// fatal logs msg at error level and exits, for startup failures
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -1,47 +1,136 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/joho/godotenv"
+	"github.com/milindkumar1/swishradar/config"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+	"github.com/milindkumar1/swishradar/internal/nba"
+	"github.com/milindkumar1/swishradar/internal/proxy"
+	"github.com/milindkumar1/swishradar/internal/ratelimit"
 )
 
+// db backs the player endpoints. It is nil when no database is configured.
+var db *database.DB
+
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	// Settings from the environment, .env and CONFIG_FILE
+	var cfg apiConfig
+	if err := config.Load(&cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	// Maintenance commands run once and exit instead of starting the server
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1:])
+		return
+	}
+
+	// Structured logs on stdout; the log package writes through them too
+	if err := logging.Setup(os.Stdout, cfg.LogFormat, cfg.LogLevel); err != nil {
+		log.Fatal(err)
 	}
+	slog.Info("configuration", "config", config.LogValue(&cfg))
+
+	// Cancelled on SIGINT or SIGTERM, which stops background jobs and starts
+	// draining the server
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Pace calls to ESPN and the NBA API so backfills don't get us blocked
+	ratelimit.SetHostLimit(espn.Host, cfg.ESPNRequestsPerMinute, cfg.ESPNBurst)
+	ratelimit.SetHostLimit(nba.Host, cfg.NBARequestsPerMinute, cfg.NBABurst)
 
 	// Initialize router
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(realIP(cfg.TrustedProxyHops))
+	r.Use(requestLogger)
+	r.Use(requestMetrics)
+	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
+		AllowedOrigins:   cfg.CORSOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
+		ExposedHeaders:   []string{"Link", "ETag", "X-Cache", "X-Request-Id", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	// ESPN Service URL
-	espnServiceURL := os.Getenv("ESPN_SERVICE_URL")
-	if espnServiceURL == "" {
-		espnServiceURL = "http://localhost:5001"
+	// Native ESPN clients, one per league. The default league uses the
+	// cookies from the environment; others use their members' cookies.
+	espnPool = espn.NewPool(dbCredentials{}, 10*time.Minute)
+	if cfg.ESPNLeagueID != "" {
+		defaultLeagueID = cfg.ESPNLeagueID
+		espnPool.Pin(cfg.ESPNLeagueID, espn.Credentials{SWID: cfg.ESPNSWID, S2: cfg.ESPNS2})
+	} else {
+		slog.Warn("ESPN_LEAGUE_ID not set, unscoped ESPN and analytics endpoints are disabled")
+	}
+
+	// Database for player stats
+	if conn, err := database.Open(context.Background(), cfg.Database); err != nil {
+		slog.Warn("database unavailable, player endpoints are disabled", "error", err)
+	} else {
+		db = conn
+		users = conn
+		defer db.Close()
+		registerPoolMetrics(db)
+
+		if cfg.MigrateOnStart {
+			migrated, err := db.MigrateUp(context.Background())
+			for _, m := range migrated {
+				slog.Info("applied migration", "version", m.Version, "name", m.Name)
+			}
+			if err != nil {
+				fatal("migration failed", "error", err)
+			}
+		}
+		// Handlers assume the current schema, so don't serve on an old one
+		if err := db.CheckSchema(context.Background()); err != nil {
+			fatal("run `go run ./cmd/api migrate up` or set MIGRATE_ON_START=true", "error", err)
+		}
+	}
+
+	// Bearer token auth for /api/v1
+	setupAuth(cfg)
+
+	// Per-caller request quotas for /api routes
+	setupRateLimits(cfg)
+
+	// Response cache for ESPN-backed routes
+	responseCache = newResponseCache(cfg.CacheBackend)
+
+	// Where notifications for league members are posted
+	discordWebhookURL = cfg.DiscordWebhookURL
+
+	// Keep each league's teams, matchups and transactions in the database
+	var workers sync.WaitGroup
+	if db != nil {
+		workers.Add(2)
+		go func() {
+			defer workers.Done()
+			runEvery(ctx, "league sync", cfg.LeagueSyncInterval, newLeagueSync().syncOnce)
+		}()
+		go func() {
+			defer workers.Done()
+			runEvery(ctx, "transaction poll", cfg.TransactionPollInterval, pollTransactions)
+		}()
 	}
 
 	// Routes
@@ -49,45 +138,70 @@ func main() {
 		w.Write([]byte("SwishRadar API v1.0"))
 	})
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":    "healthy",
-			"timestamp": time.Now(),
-		})
-	})
-
-	// ESPN Service proxy routes
-	r.Get("/api/espn/health", func(w http.ResponseWriter, r *http.Request) {
-		proxyRequest(w, r, espnServiceURL+"/health")
-	})
+	// Prometheus scrape target
+	r.Method(http.MethodGet, "/metrics", metrics.Default.Handler())
 
-	r.Get("/api/espn/league", func(w http.ResponseWriter, r *http.Request) {
-		proxyRequest(w, r, espnServiceURL+"/api/league")
-	})
-
-	r.Get("/api/espn/teams", func(w http.ResponseWriter, r *http.Request) {
-		proxyRequest(w, r, espnServiceURL+"/api/teams")
-	})
+	// Probes for the container orchestrator. /health is the readiness check
+	// under its old name for existing monitors.
+	r.Get("/health/live", handleLive)
+	r.Get("/health/ready", handleReady)
+	r.Get("/health", handleReady)
+	readinessChecks["database"] = nil
+	readinessChecks["schema"] = nil
+	readinessChecks["espn"] = nil
+	if db != nil {
+		readinessChecks["database"] = checkDatabase
+		readinessChecks["schema"] = checkSchema
+	}
 
-	r.Get("/api/espn/free-agents", func(w http.ResponseWriter, r *http.Request) {
-		url := espnServiceURL + "/api/free-agents"
-		if r.URL.RawQuery != "" {
-			url += "?" + r.URL.RawQuery
+	// ESPN routes for the default league, served natively or proxied to the
+	// ESPN service. Like the per-league routes under /api/v1 they need a
+	// bearer token from a member of the league, or the bot's.
+	espnRoutes := r.With(rateLimitIP, requireAuth, rateLimit(apiQuota), defaultLeague, requireLeagueMember)
+	switch cfg.ESPNSource {
+	case "native":
+		if defaultLeagueID != "" {
+			readinessChecks["espn"] = checkNativeESPN
 		}
-		proxyRequest(w, r, url)
-	})
-
-	r.Get("/api/espn/standings", func(w http.ResponseWriter, r *http.Request) {
-		proxyRequest(w, r, espnServiceURL+"/api/standings")
-	})
+		espnRoutes.Get("/api/espn/health", handleNativeESPNHealth)
+		espnRoutes.Method(http.MethodGet, "/api/espn/league", responseCache.Handler(leagueCachePolicy, http.HandlerFunc(handleNativeLeague)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/teams", responseCache.Handler(teamsCachePolicy, http.HandlerFunc(handleNativeTeams)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/free-agents", responseCache.Handler(freeAgentsCachePolicy, http.HandlerFunc(handleNativeFreeAgents)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/standings", responseCache.Handler(standingsCachePolicy, http.HandlerFunc(handleNativeStandings)))
+	case "service":
+		espnProxy, err := proxy.New(cfg.ESPNServiceURL, proxy.Options{Name: "ESPN service"})
+		if err != nil {
+			fatal("invalid ESPN_SERVICE_URL", "error", err)
+		}
+		registerBreakerMetrics(espnProxy)
+		readinessChecks["espn"] = func(ctx context.Context) (interface{}, error) {
+			err := espnProxy.Check(ctx, "/health")
+			return map[string]string{"circuit": espnProxy.BreakerState()}, err
+		}
+		espnRoutes.Method(http.MethodGet, "/api/espn/health", espnProxy.Route("/health", 5*time.Second))
+		espnRoutes.Method(http.MethodGet, "/api/espn/league", responseCache.Handler(leagueCachePolicy, espnProxy.Route("/api/league", 20*time.Second)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/teams", responseCache.Handler(teamsCachePolicy, espnProxy.Route("/api/teams", 20*time.Second)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/free-agents", responseCache.Handler(freeAgentsCachePolicy, espnProxy.Route("/api/free-agents", 30*time.Second)))
+		espnRoutes.Method(http.MethodGet, "/api/espn/standings", responseCache.Handler(standingsCachePolicy, espnProxy.Route("/api/standings", 20*time.Second)))
+	}
 
-	// API v1 routes (future analytics endpoints)
+	// API v1 routes (future analytics endpoints). Every route needs a bearer
+	// token; league routes also need league membership.
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(rateLimitIP)
+		r.Use(requireAuth)
+		r.Use(rateLimit(apiQuota))
+
+		r.Get("/me", handleGetMe)
+		r.Get("/me/espn-credentials", handleGetESPNCredentials)
+		r.Put("/me/espn-credentials", handlePutESPNCredentials)
+		r.Delete("/me/espn-credentials", handleDeleteESPNCredentials)
+
 		// Analytics routes
 		r.Route("/analytics", func(r chi.Router) {
+			r.Use(rateLimit(analyticsQuota))
 			r.Get("/streaming", handleGetStreamingRecommendations)
-			r.Post("/trade", handleCalculateTrade)
+			r.With(defaultLeague, requireLeagueMember).Post("/trade", handleCalculateTrade)
 			r.Get("/power-rankings", handleGetPowerRankings)
 			r.Get("/matchup/{week}", handleGetMatchupPrediction)
 		})
@@ -95,52 +209,92 @@ func main() {
 		// Player routes
 		r.Route("/players", func(r chi.Router) {
 			r.Get("/", handleGetPlayers)
-			r.Get("/{id}", handleGetPlayer)
+			r.With(defaultLeagueIfMember).Get("/{id}", handleGetPlayer)
 			r.Get("/{id}/stats", handleGetPlayerStats)
 		})
 
+		// League routes. ESPN data is read with the league's own credentials.
+		r.Route("/leagues/{leagueID}", func(r chi.Router) {
+			r.Use(leagueFromRoute)
+			r.Put("/members/{userID}", handleAddLeagueMember)
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireLeagueMember)
+				r.Get("/espn/health", handleNativeESPNHealth)
+				r.Method(http.MethodGet, "/espn/league", responseCache.Handler(leagueCachePolicy, http.HandlerFunc(handleNativeLeague)))
+				r.Method(http.MethodGet, "/espn/teams", responseCache.Handler(teamsCachePolicy, http.HandlerFunc(handleNativeTeams)))
+				r.Method(http.MethodGet, "/espn/free-agents", responseCache.Handler(freeAgentsCachePolicy, http.HandlerFunc(handleNativeFreeAgents)))
+				r.Method(http.MethodGet, "/espn/standings", responseCache.Handler(standingsCachePolicy, http.HandlerFunc(handleNativeStandings)))
+				r.With(rateLimit(analyticsQuota)).Post("/trade", handleCalculateTrade)
+				r.Get("/players/{id}", handleGetPlayer)
+				r.Get("/recaps/pending", handleGetPendingRecaps)
+				r.Post("/recaps/{season}/{week}/posted", handleMarkRecapPosted)
+				r.Get("/transactions", handleGetTransactions)
+				r.Get("/transactions/pending", handleGetPendingTransactions)
+				r.Post("/transactions/{id}/announced", handleMarkTransactionAnnounced)
+			})
+		})
+
+		// Cache routes
+		r.With(requireBot).Delete("/cache", handlePurgeCache)
+
 		// Backtesting routes
 		r.Route("/backtest", func(r chi.Router) {
+			r.Use(rateLimit(analyticsQuota))
 			r.Post("/run", handleRunBacktest)
 			r.Get("/results", handleGetBacktestResults)
 		})
 	})
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	slog.Info("SwishRadar API starting", "port", cfg.Port)
 
-	fmt.Printf("SwishRadar API starting on port %s\n", port)
-	fmt.Printf("ESPN Service: %s\n", espnServiceURL)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal(err)
+	select {
+	case err := <-serveErr:
+		fatal("server stopped", "error", err)
+	case <-ctx.Done():
 	}
+	// A second signal kills the process without waiting
+	stop()
+	shutdown(srv, &workers, cfg.ShutdownTimeout)
 }
 
-// Proxy helper function
-func proxyRequest(w http.ResponseWriter, r *http.Request, targetURL string) {
-	resp, err := http.Get(targetURL)
-	if err != nil {
-		log.Printf("Error proxying request to %s: %v", targetURL, err)
-		http.Error(w, fmt.Sprintf("Failed to connect to ESPN service: %v", err), http.StatusBadGateway)
-		return
-	}
-	defer resp.Body.Close()
+// shutdown stops accepting connections and waits for in-flight requests
+// and background jobs, whose context is already cancelled, giving up after
+// timeout
+func shutdown(srv *http.Server, workers *sync.WaitGroup, timeout time.Duration) {
+	slog.Info("shutting down", "timeout", timeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// Copy headers
-	for key, values := range resp.Header {
-		for _, value := range values {
-			w.Header().Add(key, value)
-		}
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("timed out waiting for requests", "error", err)
 	}
 
-	// Copy status code
-	w.WriteHeader(resp.StatusCode)
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Error("timed out waiting for background jobs")
+	}
 
-	// Copy body
-	io.Copy(w, resp.Body)
+	slog.Info("shutdown complete")
 }
 
 // Placeholder handlers for future analytics features
@@ -149,11 +303,6 @@ func handleGetStreamingRecommendations(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"message": "Streaming recommendations - coming soon"}`))
 }
 
-func handleCalculateTrade(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"message": "Trade calculator - coming soon"}`))
-}
-
 func handleGetPowerRankings(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"message": "Power rankings - coming soon"}`))
@@ -164,21 +313,6 @@ func handleGetMatchupPrediction(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"message": "Matchup prediction - coming soon"}`))
 }
 
-func handleGetPlayers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"message": "Players list - coming soon"}`))
-}
-
-func handleGetPlayer(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"message": "Player details - coming soon"}`))
-}
-
-func handleGetPlayerStats(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"message": "Player stats - coming soon"}`))
-}
-
 func handleRunBacktest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"message": "Backtest runner - coming soon"}`))
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+	"github.com/milindkumar1/swishradar/internal/proxy"
+)
+
+var (
+	httpRequests = metrics.Default.NewCounterVec("swishradar_http_requests_total",
+		"HTTP requests by route pattern and status.", "method", "route", "status")
+	httpDuration = metrics.Default.NewHistogramVec("swishradar_http_request_duration_seconds",
+		"HTTP request latency by route pattern.", metrics.DefaultBuckets, "method", "route")
+)
+
+func init() {
+	metrics.Default.NewGaugeFunc("swishradar_goroutines", "Goroutines currently running.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+}
+
+// requestMetrics counts and times requests by chi route pattern, so
+// /api/v1/players/{id} is one series however many players are requested
+func requestMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routePattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.Inc(r.Method, route, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// registerPoolMetrics exports the database connection pool's stats
+func registerPoolMetrics(db *database.DB) {
+	stat := func(fn func(s database.PoolStats) float64) func() float64 {
+		return func() float64 { return fn(db.PoolStats()) }
+	}
+	metrics.Default.NewGaugeFunc("swishradar_db_connections_max_open", "Maximum open database connections.",
+		stat(func(s database.PoolStats) float64 { return float64(s.MaxOpen) }))
+	metrics.Default.NewGaugeFunc("swishradar_db_connections_open", "Open database connections.",
+		stat(func(s database.PoolStats) float64 { return float64(s.Open) }))
+	metrics.Default.NewGaugeFunc("swishradar_db_connections_in_use", "Database connections in use.",
+		stat(func(s database.PoolStats) float64 { return float64(s.InUse) }))
+	metrics.Default.NewGaugeFunc("swishradar_db_connections_idle", "Idle database connections.",
+		stat(func(s database.PoolStats) float64 { return float64(s.Idle) }))
+	metrics.Default.NewCounterFunc("swishradar_db_connection_waits_total", "Times a query waited for a free connection.",
+		stat(func(s database.PoolStats) float64 { return float64(s.WaitCount) }))
+	metrics.Default.NewCounterFunc("swishradar_db_connection_wait_seconds_total", "Time spent waiting for a free connection.",
+		stat(func(s database.PoolStats) float64 { return s.WaitSeconds }))
+}
+
+// registerBreakerMetrics exports whether the proxy to the ESPN service is
+// refusing requests
+func registerBreakerMetrics(p *proxy.Proxy) {
+	metrics.Default.NewGaugeFunc("swishradar_espn_service_circuit_open", "Whether the ESPN service circuit breaker is open.", func() float64 {
+		if p.BreakerState() == "open" {
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// webhookClient posts notifications to Discord
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// discordWebhookURL is where notifications are posted. It is empty when
+// DISCORD_WEBHOOK_URL is not configured.
+var discordWebhookURL string
+
+// notifyDiscord posts content to the Discord webhook, or only logs it when
+// the webhook is not configured. Failures are logged, not returned, since
+// notifications are best effort.
+func notifyDiscord(ctx context.Context, content string) {
+	if discordWebhookURL == "" {
+		slog.InfoContext(ctx, "notification not sent, DISCORD_WEBHOOK_URL not set", "content", content)
+		return
+	}
+	if err := postWebhook(ctx, discordWebhookURL, content); err != nil {
+		logError(ctx, "error sending Discord notification", err)
+	}
+}
+
+func postWebhook(ctx context.Context, url, content string) error {
+	payload, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
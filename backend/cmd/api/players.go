@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/fuzzy"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// minSearchScore drops fuzzy matches too weak to be useful
+const minSearchScore = 0.5
+
+// playerMatch is a search result with its fuzzy match score
+type playerMatch struct {
+	models.Player
+	Score float64 `json:"score"`
+}
+
+// playerDetail is the GET /api/v1/players/{id} response
+type playerDetail struct {
+	Player         models.Player          `json:"player"`
+	SeasonAverages *models.PlayerAverages `json:"season_averages"`
+	Last14Averages *models.PlayerAverages `json:"last_14_averages"`
+	InjuryStatus   string                 `json:"injury_status,omitempty"`
+	RosterStatus   string                 `json:"roster_status,omitempty"`
+	FantasyTeam    string                 `json:"fantasy_team,omitempty"`
+	PercentOwned   *float64               `json:"percent_owned,omitempty"`
+}
+
+func handleGetPlayers(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	limit := queryInt(r, "limit", 25)
+	search := r.URL.Query().Get("search")
+
+	players, err := db.ListActivePlayers(r.Context())
+	if err != nil {
+		logError(r.Context(), "error listing players", err)
+		writeError(w, http.StatusInternalServerError, "failed to list players")
+		return
+	}
+
+	matches := make([]playerMatch, 0, len(players))
+	for _, p := range players {
+		score := 1.0
+		if search != "" {
+			score = fuzzy.Score(search, p.Name)
+			if score < minSearchScore {
+				continue
+			}
+		}
+		matches = append(matches, playerMatch{Player: p, Score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"players": matches})
+}
+
+func handleGetPlayer(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	player, ok := loadPlayer(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	detail := playerDetail{Player: *player}
+
+	var err error
+	detail.SeasonAverages, err = db.GetPlayerAverages(r.Context(), player.ID, seasonStart(now))
+	if err != nil {
+		logError(r.Context(), "error loading season averages", err)
+		writeError(w, http.StatusInternalServerError, "failed to load player averages")
+		return
+	}
+	detail.Last14Averages, err = db.GetPlayerAverages(r.Context(), player.ID, now.AddDate(0, 0, -14))
+	if err != nil {
+		logError(r.Context(), "error loading last 14 day averages", err)
+		writeError(w, http.StatusInternalServerError, "failed to load player averages")
+		return
+	}
+
+	// League context is best effort; the stats above are still useful without it
+	if client, err := leagueClient(r); err == nil && player.ESPNID != nil {
+		if info, err := client.GetPlayerInfo(r.Context(), *player.ESPNID); err != nil {
+			handleESPNRejection(r.Context(), client.LeagueID, err)
+			logError(r.Context(), "error fetching ESPN player info", err, "player_id", player.ID)
+		} else {
+			detail.InjuryStatus = info.Player.InjuryStatus
+			detail.RosterStatus = info.Status
+			if info.Player.Ownership != nil {
+				detail.PercentOwned = &info.Player.Ownership.PercentOwned
+			}
+			if info.OnTeamID != 0 {
+				detail.FantasyTeam = fantasyTeamName(r.Context(), client, info.OnTeamID)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func handleGetPlayerStats(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	player, ok := loadPlayer(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := db.GetRecentPlayerStats(r.Context(), player.ID, queryInt(r, "games", 15))
+	if err != nil {
+		logError(r.Context(), "error loading player stats", err)
+		writeError(w, http.StatusInternalServerError, "failed to load player stats")
+		return
+	}
+	if stats == nil {
+		stats = []models.PlayerStats{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"stats": stats})
+}
+
+// loadPlayer resolves the {id} URL parameter, writing an error response when
+// it is invalid or unknown
+func loadPlayer(w http.ResponseWriter, r *http.Request) (*models.Player, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid player id")
+		return nil, false
+	}
+
+	player, err := db.GetPlayer(r.Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "player not found")
+		return nil, false
+	}
+	if err != nil {
+		logError(r.Context(), "error loading player", err, "player_id", id)
+		writeError(w, http.StatusInternalServerError, "failed to load player")
+		return nil, false
+	}
+	return player, true
+}
+
+// fantasyTeamName looks up a fantasy team's display name, falling back to its ID
+func fantasyTeamName(ctx context.Context, client *espn.Client, teamID int) string {
+	league, err := cachedLeague(ctx, client)
+	if err != nil {
+		logError(ctx, "error fetching league for team name", err)
+		return "Team " + strconv.Itoa(teamID)
+	}
+	for _, team := range league.Teams {
+		if team.ID == teamID {
+			return team.DisplayName()
+		}
+	}
+	return "Team " + strconv.Itoa(teamID)
+}
+
+// seasonStart returns October 1st of the NBA season containing t
+func seasonStart(t time.Time) time.Time {
+	year := t.Year()
+	if t.Month() < time.October {
+		year--
+	}
+	return time.Date(year, time.October, 1, 0, 0, 0, 0, t.Location())
+}
+
+// queryInt reads a positive integer query parameter, returning def when it
+// is missing or invalid
+func queryInt(r *http.Request, key string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/ratelimit"
+)
+
+// routeQuota limits one group of routes. Users are limited by ID, callers
+// not yet authenticated by IP, and the bot has a budget of its own.
+type routeQuota struct {
+	name    string
+	perUser *ratelimit.Limiter
+	bot     *ratelimit.Limiter
+}
+
+// apiQuota covers every /api route; analyticsQuota additionally covers the
+// expensive ones, like the matchup simulator and trade calculator. ipQuota
+// is checked before the token, by IP. setupRateLimits fills them in.
+var apiQuota, analyticsQuota, ipQuota *routeQuota
+
+// setupRateLimits creates the route quotas from the settings
+func setupRateLimits(cfg apiConfig) {
+	apiQuota = &routeQuota{
+		name:    "api",
+		perUser: ratelimit.NewLimiter(cfg.RateLimit, cfg.RateLimit),
+		bot:     ratelimit.NewLimiter(cfg.BotRateLimit, cfg.BotRateLimit),
+	}
+	analyticsQuota = &routeQuota{
+		name:    "analytics",
+		perUser: ratelimit.NewLimiter(cfg.AnalyticsRateLimit, cfg.AnalyticsRateLimit),
+		bot:     ratelimit.NewLimiter(cfg.BotRateLimit, cfg.BotRateLimit),
+	}
+	ipQuota = &routeQuota{
+		name:    "ip",
+		perUser: ratelimit.NewLimiter(cfg.IPRateLimit, cfg.IPRateLimit),
+	}
+}
+
+// rateLimitIP applies ipQuota ahead of requireAuth, so guessing tokens,
+// and the database lookup each guess costs, is limited too. The bot token
+// skips it for the bot quota.
+func rateLimitIP(next http.Handler) http.Handler {
+	limited := rateLimit(ipQuota)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := bearerToken(r); ok && isBotToken(token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// rateLimit answers 429 once a caller has used up q. Every response reports
+// the caller's standing in RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset, with the innermost quota winning when routes are under
+// more than one. Past requireAuth it limits by principal, before it by IP.
+func rateLimit(q *routeQuota) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := q.perUser
+			if p := currentPrincipal(r); p != nil && p.Bot {
+				limiter = q.bot
+			}
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			d := limiter.Allow(clientKey(r))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(seconds(d.Reset)))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=60", d.Limit))
+			if !d.Allowed {
+				retry := seconds(d.RetryAfter)
+				h.Set("Retry-After", strconv.Itoa(retry))
+				writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+					"error":       fmt.Sprintf("rate limit exceeded, try again in %ds", retry),
+					"code":        "rate_limited",
+					"quota":       q.name,
+					"retry_after": retry,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies who a request counts against: the signed-in user,
+// the bot, or else the client's IP as set by realIP
+func clientKey(r *http.Request) string {
+	if p := currentPrincipal(r); p != nil {
+		if p.Bot {
+			return "bot"
+		}
+		if p.User != nil {
+			return "user:" + strconv.Itoa(p.User.ID)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// realIP sets RemoteAddr to the client's IP from X-Forwarded-For when the
+// API runs behind hops proxies. Each proxy appends the address it was
+// called from, so only the last hops entries can be trusted; anything to
+// their left came from the client. With hops 0 the header is ignored.
+func realIP(hops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedFor(r.Header.Values("X-Forwarded-For"), hops); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor returns the address the outermost of hops proxies appended
+// to the X-Forwarded-For values, or "" if there is none
+func forwardedFor(values []string, hops int) string {
+	if hops <= 0 {
+		return ""
+	}
+	var ips []string
+	for _, v := range values {
+		for _, ip := range strings.Split(v, ",") {
+			ips = append(ips, strings.TrimSpace(ip))
+		}
+	}
+	if len(ips) == 0 {
+		return ""
+	}
+	// With fewer entries than proxies, every entry came from a proxy
+	i := len(ips) - hops
+	if i < 0 {
+		i = 0
+	}
+	if net.ParseIP(ips[i]) == nil {
+		return ""
+	}
+	return ips[i]
+}
+
+// seconds rounds d up to whole seconds for headers
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/models"
+	"github.com/milindkumar1/swishradar/internal/ratelimit"
+)
+
+// as returns r signed in as p, as requireAuth would leave it
+func as(r *http.Request, p *principal) *http.Request {
+	if p == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+}
+
+func TestRateLimit(t *testing.T) {
+	alice := &principal{User: &models.User{ID: 1}}
+	bob := &principal{User: &models.User{ID: 2}}
+	bot := &principal{Bot: true}
+
+	type call struct {
+		who        *principal
+		remoteAddr string
+		want       int
+	}
+	tests := []struct {
+		name  string
+		quota *routeQuota
+		calls []call
+	}{
+		{
+			name:  "per user",
+			quota: &routeQuota{name: "api", perUser: ratelimit.NewLimiter(1, 2), bot: ratelimit.NewLimiter(1, 1)},
+			calls: []call{
+				{alice, "10.0.0.1:1000", http.StatusOK},
+				{alice, "10.0.0.2:1000", http.StatusOK},
+				{alice, "10.0.0.3:1000", http.StatusTooManyRequests},
+				{bob, "10.0.0.1:1000", http.StatusOK},
+			},
+		},
+		{
+			name:  "per IP without a token",
+			quota: &routeQuota{name: "api", perUser: ratelimit.NewLimiter(1, 1)},
+			calls: []call{
+				{nil, "10.0.0.1:1000", http.StatusOK},
+				{nil, "10.0.0.1:2000", http.StatusTooManyRequests},
+				{nil, "10.0.0.2:1000", http.StatusOK},
+				{alice, "10.0.0.1:1000", http.StatusOK},
+			},
+		},
+		{
+			name:  "bot has its own budget",
+			quota: &routeQuota{name: "api", perUser: ratelimit.NewLimiter(1, 1), bot: ratelimit.NewLimiter(1, 3)},
+			calls: []call{
+				{alice, "10.0.0.1:1000", http.StatusOK},
+				{alice, "10.0.0.1:1000", http.StatusTooManyRequests},
+				{bot, "10.0.0.1:1000", http.StatusOK},
+				{bot, "10.0.0.1:1000", http.StatusOK},
+				{bot, "10.0.0.1:1000", http.StatusOK},
+				{bot, "10.0.0.1:1000", http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:  "unlimited",
+			quota: &routeQuota{name: "api"},
+			calls: []call{
+				{alice, "10.0.0.1:1000", http.StatusOK},
+				{alice, "10.0.0.1:1000", http.StatusOK},
+				{bot, "10.0.0.1:1000", http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := rateLimit(tt.quota)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			for i, c := range tt.calls {
+				r := httptest.NewRequest(http.MethodGet, "/api/v1/players", nil)
+				r.RemoteAddr = c.remoteAddr
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, as(r, c.who))
+				if w.Code != c.want {
+					t.Errorf("call %d: status = %d, want %d", i+1, w.Code, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	q := &routeQuota{name: "analytics", perUser: ratelimit.NewLimiter(60, 2)}
+	h := rateLimit(q)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/analytics/trade", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send()
+	tests := []struct {
+		header, want string
+	}{
+		{"RateLimit-Limit", "2"},
+		{"RateLimit-Remaining", "1"},
+		{"RateLimit-Reset", "1"},
+		{"RateLimit-Policy", "2;w=60"},
+		{"Retry-After", ""},
+	}
+	for _, tt := range tests {
+		if got := w.Header().Get(tt.header); got != tt.want {
+			t.Errorf("allowed: %s = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+
+	send()
+	w = send()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	var body struct {
+		Error      string `json:"error"`
+		Code       string `json:"code"`
+		Quota      string `json:"quota"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "rate_limited" || body.Quota != "analytics" || body.RetryAfter != 1 || body.Error == "" {
+		t.Errorf("429 body = %+v", body)
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	withAuth(t, &fakeUsers{})
+	old := ipQuota
+	ipQuota = &routeQuota{name: "ip", perUser: ratelimit.NewLimiter(1, 1)}
+	defer func() { ipQuota = old }()
+
+	h := rateLimitIP(requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	tests := []struct {
+		name       string
+		token      string
+		remoteAddr string
+		want       int
+	}{
+		{"bad token", "sr_guess1", "10.0.0.1:1000", http.StatusUnauthorized},
+		{"another guess", "sr_guess2", "10.0.0.1:2000", http.StatusTooManyRequests},
+		{"no token", "", "10.0.0.1:1000", http.StatusTooManyRequests},
+		{"other IP", "sr_guess3", "10.0.0.2:1000", http.StatusUnauthorized},
+		{"bot", "bot-token", "10.0.0.1:1000", http.StatusOK},
+		{"bot again", "bot-token", "10.0.0.1:1000", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		hops   int
+		want   string
+	}{
+		{"no proxy", []string{"1.2.3.4"}, 0, ""},
+		{"one proxy", []string{"1.2.3.4"}, 1, "1.2.3.4"},
+		{"spoofed entry", []string{"6.6.6.6, 1.2.3.4"}, 1, "1.2.3.4"},
+		{"two proxies", []string{"6.6.6.6, 1.2.3.4, 10.0.0.5"}, 2, "1.2.3.4"},
+		{"separate headers", []string{"6.6.6.6", "1.2.3.4"}, 1, "1.2.3.4"},
+		{"fewer entries than proxies", []string{"1.2.3.4"}, 2, "1.2.3.4"},
+		{"IPv6", []string{"2001:db8::1"}, 1, "2001:db8::1"},
+		{"not an IP", []string{"1.2.3.4, localhost"}, 1, ""},
+		{"no header", nil, 1, ""},
+	}
+	for _, tt := range tests {
+		if got := forwardedFor(tt.values, tt.hops); got != tt.want {
+			t.Errorf("%s: forwardedFor = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	var got string
+	h := realIP(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = clientKey(r)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.5:1000"
+	r.Header.Set("X-Forwarded-For", "6.6.6.6, 1.2.3.4")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "ip:1.2.3.4" {
+		t.Errorf("behind a proxy: clientKey = %q, want ip:1.2.3.4", got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.5:1000"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "ip:10.0.0.5" {
+		t.Errorf("without the header: clientKey = %q, want ip:10.0.0.5", got)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		who        *principal
+		remoteAddr string
+		want       string
+	}{
+		{"user", &principal{User: &models.User{ID: 7}}, "10.0.0.1:1000", "user:7"},
+		{"bot", &principal{Bot: true}, "10.0.0.1:1000", "bot"},
+		{"anonymous", nil, "10.0.0.1:1000", "ip:10.0.0.1"},
+		{"IPv6", nil, "[2001:db8::1]:1000", "ip:2001:db8::1"},
+		{"no port", nil, "10.0.0.1", "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if got := clientKey(as(r, tt.who)); got != tt.want {
+			t.Errorf("%s: clientKey = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{time.Second + time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		if got := seconds(tt.d); got != tt.want {
+			t.Errorf("seconds(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/milindkumar1/swishradar/internal/analytics"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// handleGetPendingRecaps returns a recap for every finished scoring period
+// that has not been posted yet, oldest first
+func handleGetPendingRecaps(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	leagueID := chi.URLParam(r, "leagueID")
+	matchups, err := db.ListPendingRecaps(r.Context(), leagueID, time.Now())
+	if err != nil {
+		logError(r.Context(), "error listing pending recaps", err)
+		writeError(w, http.StatusInternalServerError, "failed to load matchups")
+		return
+	}
+
+	// Matchups arrive ordered by season and week, so periods are contiguous
+	recaps := make([]analytics.WeekRecap, 0)
+	for start := 0; start < len(matchups); {
+		end := start
+		for end < len(matchups) && matchups[end].Season == matchups[start].Season && matchups[end].Week == matchups[start].Week {
+			end++
+		}
+
+		week := analytics.BuildWeekRecap(matchups[start:end])
+		for i := range week.Matchups {
+			m := &week.Matchups[i]
+			m.Team1.MVP = teamMVP(r, m.Team1.ID, week)
+			m.Team2.MVP = teamMVP(r, m.Team2.ID, week)
+		}
+		recaps = append(recaps, week)
+		start = end
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recaps": recaps})
+}
+
+// handleMarkRecapPosted records that the bot posted a period's recap so it is
+// not posted again
+func handleMarkRecapPosted(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	season, err := strconv.Atoi(chi.URLParam(r, "season"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid season")
+		return
+	}
+	week, err := strconv.Atoi(chi.URLParam(r, "week"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid week")
+		return
+	}
+
+	err = db.MarkRecapPosted(r.Context(), chi.URLParam(r, "leagueID"), season, week, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "no unposted recap for that week")
+		return
+	}
+	if err != nil {
+		logError(r.Context(), "error marking recap posted", err)
+		writeError(w, http.StatusInternalServerError, "failed to mark recap posted")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// teamMVP looks up a team's best player for the recap period. Missing
+// period dates or stats simply leave the MVP out.
+func teamMVP(r *http.Request, teamID int, week analytics.WeekRecap) *models.TeamMVP {
+	if week.PeriodStart == nil || week.PeriodEnd == nil {
+		return nil
+	}
+	mvp, err := db.GetTeamMVP(r.Context(), teamID, *week.PeriodStart, *week.PeriodEnd)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			logError(r.Context(), "error loading team MVP", err, "team_id", teamID)
+		}
+		return nil
+	}
+	return mvp
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/analytics"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// runEvery calls fn immediately and then every interval until ctx is done,
+// logging failures against name. Each run gets its own timeout within ctx,
+// and everything it logs is tagged with the job name.
+func runEvery(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx = logging.With(ctx, "job", name)
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		// A run cut short by shutdown isn't a failure
+		if err := fn(runCtx); err != nil && ctx.Err() == nil {
+			logError(runCtx, "error running "+name, err)
+		}
+		cancel()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// leagueSync copies each active league's teams and schedule into the
+// database so recaps and predictions can read them
+type leagueSync struct {
+	// backfilled holds leagues whose past periods have all been synced;
+	// after that only the current and previous periods are refreshed
+	backfilled map[string]bool
+}
+
+func newLeagueSync() *leagueSync {
+	return &leagueSync{backfilled: make(map[string]bool)}
+}
+
+func (s *leagueSync) syncOnce(ctx context.Context) error {
+	return forEachLeague(ctx, "league sync", s.syncLeague)
+}
+
+func (s *leagueSync) syncLeague(ctx context.Context, client *espn.Client) error {
+	league, err := client.GetLeague(ctx)
+	if err != nil {
+		handleESPNRejection(ctx, client.LeagueID, err)
+		return err
+	}
+	leagueID := client.LeagueID
+
+	season := league.Season
+	if season == 0 {
+		season = client.Season
+	}
+	if err := db.UpsertLeague(ctx, leagueID, season, league.Settings.Name); err != nil {
+		return err
+	}
+
+	// ESPN team ID -> teams row ID, and -> roster for predictions
+	teamIDs := make(map[int]int, len(league.Teams))
+	rosters := make(map[int]analytics.Roster, len(league.Teams))
+	for _, team := range league.Teams {
+		rosters[team.ID] = analytics.RosterFromESPN(team)
+
+		roster := make([]models.RosterSlot, 0, len(team.Roster.Entries))
+		for _, entry := range team.Roster.Entries {
+			p := entry.PlayerPoolEntry.Player
+			roster = append(roster, models.RosterSlot{ESPNID: p.ID, Name: p.FullName})
+		}
+		id, err := db.UpsertTeam(ctx, leagueID, team.ID, team.DisplayName(), roster)
+		if err != nil {
+			return err
+		}
+		teamIDs[team.ID] = id
+	}
+
+	current := league.Status.CurrentMatchupPeriod
+	first := current - 1
+	if !s.backfilled[leagueID] {
+		first = 1
+	}
+	if first < 1 {
+		first = 1
+	}
+
+	// The next period is synced too so its prediction is stored before it
+	// starts
+	last := current
+	if len(league.ScoringPeriods(current+1)) > 0 {
+		last = current + 1
+	}
+
+	for period := first; period <= last; period++ {
+		matchups, err := client.GetMatchups(ctx, period)
+		if err != nil {
+			handleESPNRejection(ctx, client.LeagueID, err)
+			return err
+		}
+		start, end := periodDates(league, period, time.Now())
+		for _, m := range matchups {
+			if m.Away == nil {
+				continue // bye
+			}
+			row, err := matchupRow(leagueID, season, m, teamIDs, start, end)
+			if err != nil {
+				return err
+			}
+			if err := db.UpsertMatchup(ctx, row); err != nil {
+				return err
+			}
+			if period > current {
+				predictMatchup(&row, m, rosters, teamIDs)
+				if err := db.SetMatchupPrediction(ctx, row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	s.backfilled[leagueID] = true
+	return nil
+}
+
+// matchupRow converts an ESPN matchup into a matchups row with the home team
+// as team 1. Category results are only stored once ESPN has decided the
+// matchup, so recaps never see partial periods.
+func matchupRow(leagueID string, season int, m espn.Matchup, teamIDs map[int]int, start, end *time.Time) (models.Matchup, error) {
+	team1, ok1 := teamIDs[m.Home.TeamID]
+	team2, ok2 := teamIDs[m.Away.TeamID]
+	if !ok1 || !ok2 {
+		return models.Matchup{}, fmt.Errorf("matchup %d references unknown team", m.ID)
+	}
+
+	row := models.Matchup{
+		LeagueID:    leagueID,
+		Week:        m.MatchupPeriodID,
+		Season:      season,
+		Team1ID:     team1,
+		Team2ID:     team2,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+	score1, score2 := float64(m.Home.CumulativeScore.Wins), float64(m.Away.CumulativeScore.Wins)
+	row.Team1Score, row.Team2Score = &score1, &score2
+
+	if m.Decided() {
+		row.CategoryResults = analytics.CategoryResultsFromESPN(m.Home, *m.Away)
+		if winner := m.WinnerTeamID(); winner != 0 {
+			id := teamIDs[winner]
+			row.ActualWinner = &id
+		}
+	}
+	return row, nil
+}
+
+// predictMatchup fills in row's prediction from both teams' current rosters
+func predictMatchup(row *models.Matchup, m espn.Matchup, rosters map[int]analytics.Roster, teamIDs map[int]int) {
+	categories, favoured := analytics.PredictMatchup(rosters[m.Home.TeamID], rosters[m.Away.TeamID])
+	row.PredictedCategories = categories
+	switch favoured {
+	case 1:
+		id := teamIDs[m.Home.TeamID]
+		row.PredictedWinner = &id
+	case 2:
+		id := teamIDs[m.Away.TeamID]
+		row.PredictedWinner = &id
+	}
+}
+
+// periodDates returns the first and last day of a matchup period. ESPN
+// numbers scoring periods by day, so dates are counted back from the latest
+// scoring period, which is today.
+func periodDates(league *espn.League, period int, now time.Time) (*time.Time, *time.Time) {
+	days := league.ScoringPeriods(period)
+	latest := league.Status.LatestScoringPeriod
+	if len(days) == 0 || latest == 0 {
+		return nil, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(0, 0, days[0]-latest)
+	end := today.AddDate(0, 0, days[len(days)-1]-latest)
+	return &start, &end
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/milindkumar1/swishradar/internal/analytics"
+)
+
+// tradeRequest names the players each side sends. Give players must all be
+// on one team and get players on another.
+type tradeRequest struct {
+	Give []string `json:"give"`
+	Get  []string `json:"get"`
+}
+
+// rosteredPlayer locates a player on a league roster
+type rosteredPlayer struct {
+	ID     int
+	Name   string
+	TeamID int
+}
+
+func handleCalculateTrade(w http.ResponseWriter, r *http.Request) {
+	client, ok := loadLeagueClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req tradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Give) == 0 || len(req.Get) == 0 {
+		writeError(w, http.StatusBadRequest, "both give and get must list at least one player")
+		return
+	}
+
+	league, err := cachedLeague(r.Context(), client)
+	if err != nil {
+		logError(r.Context(), "error fetching league for trade", err)
+		writeError(w, http.StatusBadGateway, "failed to fetch league from ESPN")
+		return
+	}
+
+	var rostered []rosteredPlayer
+	for _, team := range league.Teams {
+		for _, entry := range team.Roster.Entries {
+			p := entry.PlayerPoolEntry.Player
+			rostered = append(rostered, rosteredPlayer{ID: p.ID, Name: p.FullName, TeamID: team.ID})
+		}
+	}
+
+	giveTeam, giveIDs, err := resolveTradeSide(rostered, req.Give)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	getTeam, getIDs, err := resolveTradeSide(rostered, req.Get)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if giveTeam == getTeam {
+		writeError(w, http.StatusBadRequest, "give and get players are on the same team")
+		return
+	}
+
+	var a, b analytics.Roster
+	for _, team := range league.Teams {
+		switch team.ID {
+		case giveTeam:
+			a = analytics.RosterFromESPN(team)
+		case getTeam:
+			b = analytics.RosterFromESPN(team)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, analytics.EvaluateTrade(a, b, giveIDs, getIDs))
+}
+
+// resolveTradeSide matches player names to rostered players and returns the
+// single team that owns them
+func resolveTradeSide(rostered []rosteredPlayer, names []string) (int, []int, error) {
+	teamID := -1
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		p, err := findRosteredPlayer(rostered, name)
+		if err != nil {
+			return 0, nil, err
+		}
+		if teamID != -1 && p.TeamID != teamID {
+			return 0, nil, fmt.Errorf("players on one side of a trade must come from the same team (%s)", p.Name)
+		}
+		teamID = p.TeamID
+		ids = append(ids, p.ID)
+	}
+	return teamID, ids, nil
+}
+
+// findRosteredPlayer matches a name exactly (case-insensitive) or, failing
+// that, by a unique substring
+func findRosteredPlayer(rostered []rosteredPlayer, name string) (rosteredPlayer, error) {
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" {
+		return rosteredPlayer{}, fmt.Errorf("empty player name")
+	}
+
+	var partial []rosteredPlayer
+	for _, p := range rostered {
+		lower := strings.ToLower(p.Name)
+		if lower == query {
+			return p, nil
+		}
+		if strings.Contains(lower, query) {
+			partial = append(partial, p)
+		}
+	}
+
+	switch len(partial) {
+	case 0:
+		return rosteredPlayer{}, fmt.Errorf("no rostered player matches %q", name)
+	case 1:
+		return partial[0], nil
+	default:
+		return rosteredPlayer{}, fmt.Errorf("%q matches %d rostered players", name, len(partial))
+	}
+}
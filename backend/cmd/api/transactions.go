@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/milindkumar1/swishradar/internal/analytics"
+	"github.com/milindkumar1/swishradar/internal/database"
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// pollTransactions stores any new moves from each active league's ESPN
+// activity feed
+func pollTransactions(ctx context.Context) error {
+	return forEachLeague(ctx, "transaction poll", pollLeagueTransactions)
+}
+
+func pollLeagueTransactions(ctx context.Context, client *espn.Client) error {
+	recent, err := client.GetTransactions(ctx, 50)
+	if err != nil {
+		handleESPNRejection(ctx, client.LeagueID, err)
+		return err
+	}
+	if len(recent) == 0 {
+		return nil
+	}
+
+	league, err := cachedLeague(ctx, client)
+	if err != nil {
+		return err
+	}
+	season := league.Season
+	if season == 0 {
+		season = client.Season
+	}
+	// Transactions reference the league row, which the league sync may not
+	// have created yet
+	if err := db.UpsertLeague(ctx, client.LeagueID, season, league.Settings.Name); err != nil {
+		return err
+	}
+
+	teamNames := make(map[int]string, len(league.Teams))
+	playerNames := make(map[int]string)
+	for _, team := range league.Teams {
+		teamNames[team.ID] = team.DisplayName()
+		for _, entry := range team.Roster.Entries {
+			p := entry.PlayerPoolEntry.Player
+			playerNames[p.ID] = p.FullName
+		}
+	}
+
+	// Dropped players are no longer on a roster, so look them up
+	var missing []int
+	for _, t := range recent {
+		for _, m := range t.Moves {
+			if _, ok := playerNames[m.PlayerID]; !ok {
+				missing = append(missing, m.PlayerID)
+				playerNames[m.PlayerID] = ""
+			}
+		}
+	}
+	if len(missing) > 0 {
+		players, err := client.GetPlayers(ctx, missing)
+		if err != nil {
+			handleESPNRejection(ctx, client.LeagueID, err)
+			logError(ctx, "error resolving transaction player names", err)
+		}
+		for _, p := range players {
+			playerNames[p.Player.ID] = p.Player.FullName
+		}
+	}
+
+	transactions := make([]models.Transaction, 0, len(recent))
+	for _, t := range recent {
+		transactions = append(transactions, transactionRow(client.LeagueID, t, teamNames, playerNames))
+	}
+
+	n, err := db.InsertTransactions(ctx, transactions)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "stored new transactions", "count", n)
+	}
+	return nil
+}
+
+func transactionRow(leagueID string, t espn.Transaction, teamNames, playerNames map[int]string) models.Transaction {
+	row := models.Transaction{
+		LeagueID:   leagueID,
+		ESPNID:     t.ID,
+		Type:       t.Type,
+		OccurredAt: t.Date,
+	}
+	for _, m := range t.Moves {
+		row.Moves = append(row.Moves, models.TransactionMove{
+			Action:         m.Action,
+			PlayerESPNID:   m.PlayerID,
+			PlayerName:     playerNames[m.PlayerID],
+			TeamESPNID:     m.TeamID,
+			TeamName:       teamNames[m.TeamID],
+			FromTeamESPNID: m.FromTeamID,
+			FromTeamName:   teamNames[m.FromTeamID],
+			Waiver:         m.Waiver,
+		})
+	}
+	return row
+}
+
+// handleGetTransactions lists a league's moves, newest first. ?since= takes
+// an RFC 3339 time and ?limit= caps the count (default 50, max 200).
+func handleGetTransactions(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+			return
+		}
+		since = t
+	}
+	limit := queryInt(r, "limit", 50)
+	if limit > 200 {
+		limit = 200
+	}
+
+	transactions, err := db.ListTransactions(r.Context(), chi.URLParam(r, "leagueID"), since, limit)
+	if err != nil {
+		logError(r.Context(), "error listing transactions", err)
+		writeError(w, http.StatusInternalServerError, "failed to load transactions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transactions": transactions})
+}
+
+// transactionAnnouncement is a transaction ready for the bot to post
+type transactionAnnouncement struct {
+	models.Transaction
+	Grades []analytics.TransactionGrade `json:"grades"`
+	// RecentValues holds fantasy value per game over the last two weeks,
+	// keyed by ESPN player ID, for players with recent games
+	RecentValues map[int]float64 `json:"recent_values"`
+}
+
+// handleGetPendingTransactions returns transactions from the last three days
+// that have not been announced, oldest first, graded by the recent fantasy
+// value of the players involved
+func handleGetPendingTransactions(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	now := time.Now()
+	transactions, err := db.ListUnannouncedTransactions(r.Context(), chi.URLParam(r, "leagueID"), now.AddDate(0, 0, -3), 25)
+	if err != nil {
+		logError(r.Context(), "error listing pending transactions", err)
+		writeError(w, http.StatusInternalServerError, "failed to load transactions")
+		return
+	}
+
+	var ids []int
+	for _, t := range transactions {
+		for _, m := range t.Moves {
+			ids = append(ids, m.PlayerESPNID)
+		}
+	}
+	values, err := db.GetRecentFantasyValues(r.Context(), ids, now.AddDate(0, 0, -14))
+	if err != nil {
+		logError(r.Context(), "error loading recent fantasy values", err)
+		writeError(w, http.StatusInternalServerError, "failed to load player values")
+		return
+	}
+
+	announcements := make([]transactionAnnouncement, 0, len(transactions))
+	for _, t := range transactions {
+		a := transactionAnnouncement{
+			Transaction:  t,
+			Grades:       analytics.GradeTransaction(t, values),
+			RecentValues: make(map[int]float64),
+		}
+		for _, m := range t.Moves {
+			if v, ok := values[m.PlayerESPNID]; ok {
+				a.RecentValues[m.PlayerESPNID] = v
+			}
+		}
+		announcements = append(announcements, a)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transactions": announcements})
+}
+
+// handleMarkTransactionAnnounced records that the bot posted a transaction
+func handleMarkTransactionAnnounced(w http.ResponseWriter, r *http.Request) {
+	if db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database is not configured")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+
+	err = db.MarkTransactionAnnounced(r.Context(), chi.URLParam(r, "leagueID"), id)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "no unannounced transaction with that id")
+		return
+	}
+	if err != nil {
+		logError(r.Context(), "error marking transaction announced", err)
+		writeError(w, http.StatusInternalServerError, "failed to mark transaction announced")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,279 @@
+// Package config loads typed settings for the API and the Discord bot.
+//
+// Each setting is a struct field tagged with the environment variable it is
+// read from:
+//
+//	type Config struct {
+//		Port    string        `env:"PORT" default:"8081"`
+//		Token   string        `env:"DISCORD_TOKEN" required:"true" secret:"true"`
+//		Origins []string      `env:"CORS_ORIGINS" default:"http://localhost:3000"`
+//		Timeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s"`
+//	}
+//
+// Values come from, highest precedence first: the process environment, a
+// .env file in the working directory, the YAML file named by CONFIG_FILE,
+// and the default tag. Empty values count as unset. Fields without an env
+// tag that are structs are filled the same way, so a package can tag its
+// own settings and be embedded in a larger config.
+//
+// The Discord bot imports this package through a replace directive pointing
+// at ../backend, so it builds from a full checkout of the repository.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileVar names the variable holding the path of the optional YAML file.
+// Its keys are the same names as the environment variables.
+const FileVar = "CONFIG_FILE"
+
+// Error lists every problem found while loading a config, so they can all
+// be fixed at once
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validator is implemented by configs with checks beyond required fields.
+// Validate should report every problem it finds, joined with errors.Join.
+type Validator interface {
+	Validate() error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load fills the struct dst points to and validates it. Problems with
+// individual values, missing required values and the config's own
+// Validate errors are returned together as an *Error.
+func Load(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Load needs a pointer to a struct, got %T", dst)
+	}
+
+	lookup, err := sources()
+	if err != nil {
+		return &Error{Problems: []string{err.Error()}}
+	}
+
+	var problems []string
+	eachField(v.Elem(), func(f reflect.StructField, fv reflect.Value, key string) {
+		// A bad value leaves the default in place, so Validate doesn't
+		// report the same setting a second time
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if err := setValue(fv, def); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid default %q: %v", key, def, err))
+			}
+		}
+		raw, ok := lookup(key)
+		if !ok {
+			if f.Tag.Get("required") == "true" {
+				problems = append(problems, key+" is required")
+			}
+			return
+		}
+		if err := setValue(fv, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	})
+
+	if val, ok := dst.(Validator); ok {
+		problems = append(problems, flatten(val.Validate())...)
+	}
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// Print writes the settings of a loaded config to w, one KEY=value line
+// each, with secret values masked
+func Print(w io.Writer, cfg interface{}) {
+	eachField(reflect.Indirect(reflect.ValueOf(cfg)), func(f reflect.StructField, fv reflect.Value, key string) {
+		fmt.Fprintf(w, "  %s=%s\n", key, display(f, fv))
+	})
+}
+
+// LogValue returns the settings of a loaded config as a slog group, with
+// secret values masked
+func LogValue(cfg interface{}) slog.Value {
+	var attrs []slog.Attr
+	eachField(reflect.Indirect(reflect.ValueOf(cfg)), func(f reflect.StructField, fv reflect.Value, key string) {
+		attrs = append(attrs, slog.String(key, display(f, fv)))
+	})
+	return slog.GroupValue(attrs...)
+}
+
+// sources returns a lookup over the environment, .env and the YAML file
+func sources() (func(key string) (string, bool), error) {
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+
+	env := func(key string) (string, bool) {
+		if v := os.Getenv(key); v != "" {
+			return v, true
+		}
+		if v := dotenv[key]; v != "" {
+			return v, true
+		}
+		return "", false
+	}
+
+	path, ok := env(FileVar)
+	if !ok {
+		return env, nil
+	}
+	file, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", FileVar, err)
+	}
+	return func(key string) (string, bool) {
+		if v, ok := env(key); ok {
+			return v, true
+		}
+		if v := file[key]; v != "" {
+			return v, true
+		}
+		return "", false
+	}, nil
+}
+
+// readFile reads a flat YAML mapping of setting names to values. Names are
+// matched case-insensitively and lists become comma-separated values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		key := strings.ToUpper(k)
+		switch v := v.(type) {
+		case nil:
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("%s: nested settings are not supported", k)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// eachField calls fn for every env-tagged field of v, descending into
+// untagged struct fields
+func eachField(v reflect.Value, fn func(f reflect.StructField, fv reflect.Value, key string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key := f.Tag.Get("env")
+		if key == "" {
+			if fv.Kind() == reflect.Struct {
+				eachField(fv, fn)
+			}
+			continue
+		}
+		fn(f, fv, key)
+	}
+}
+
+func setValue(fv reflect.Value, raw string) error {
+	switch {
+	case fv.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s", raw)
+		}
+		fv.SetInt(int64(d))
+	case fv.Kind() == reflect.String:
+		fv.SetString(raw)
+	case fv.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		fv.SetBool(b)
+	case fv.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		fv.SetInt(int64(n))
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", fv.Type())
+	}
+	return nil
+}
+
+func display(f reflect.StructField, fv reflect.Value) string {
+	if f.Tag.Get("secret") == "true" {
+		if fv.IsZero() {
+			return ""
+		}
+		return "[redacted]"
+	}
+	switch {
+	case fv.Type() == durationType:
+		return time.Duration(fv.Int()).String()
+	case fv.Kind() == reflect.Slice:
+		return strings.Join(fv.Interface().([]string), ",")
+	}
+	return fmt.Sprint(fv.Interface())
+}
+
+// flatten splits an error from Validate into one problem per joined error
+func flatten(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var cfgErr *Error
+	if errors.As(err, &cfgErr) {
+		return cfgErr.Problems
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var problems []string
+		for _, e := range joined.Unwrap() {
+			problems = append(problems, flatten(e)...)
+		}
+		return problems
+	}
+	return []string{err.Error()}
+}
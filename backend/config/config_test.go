@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Token   string        `env:"TEST_TOKEN" required:"true" secret:"true"`
+	Port    string        `env:"TEST_PORT" default:"8081"`
+	Origins []string      `env:"TEST_ORIGINS" default:"http://localhost:3000"`
+	Timeout time.Duration `env:"TEST_TIMEOUT" default:"15s"`
+	Workers int           `env:"TEST_WORKERS" default:"4"`
+	Debug   bool          `env:"TEST_DEBUG"`
+	Key     string        `env:"TEST_KEY" secret:"true"`
+
+	Database struct {
+		URL string `env:"TEST_DATABASE_URL" default:"postgres://localhost/test"`
+	}
+}
+
+func (c *testConfig) Validate() error {
+	if c.Workers > 100 {
+		return errors.Join(errors.New("TEST_WORKERS must be at most 100"), errors.New("TEST_WORKERS is too many"))
+	}
+	return nil
+}
+
+// isolate runs the test in an empty directory, so no .env is read, with
+// every setting unset
+func isolate(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	for _, key := range []string{FileVar, "TEST_TOKEN", "TEST_PORT", "TEST_ORIGINS", "TEST_TIMEOUT", "TEST_WORKERS", "TEST_DEBUG", "TEST_KEY", "TEST_DATABASE_URL"} {
+		t.Setenv(key, "")
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	defaults := testConfig{
+		Token:   "t0ken",
+		Port:    "8081",
+		Origins: []string{"http://localhost:3000"},
+		Timeout: 15 * time.Second,
+		Workers: 4,
+	}
+	defaults.Database.URL = "postgres://localhost/test"
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		want         func(c *testConfig)
+		wantProblems []string
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"TEST_TOKEN": "t0ken"},
+		},
+		{
+			name: "environment",
+			env: map[string]string{
+				"TEST_TOKEN":        "t0ken",
+				"TEST_PORT":         "9000",
+				"TEST_ORIGINS":      "https://a.example, ,https://b.example",
+				"TEST_TIMEOUT":      "1m",
+				"TEST_WORKERS":      "8",
+				"TEST_DEBUG":        "true",
+				"TEST_DATABASE_URL": "postgres://db/prod",
+			},
+			want: func(c *testConfig) {
+				c.Port = "9000"
+				c.Origins = []string{"https://a.example", "https://b.example"}
+				c.Timeout = time.Minute
+				c.Workers = 8
+				c.Debug = true
+				c.Database.URL = "postgres://db/prod"
+			},
+		},
+		{
+			name:         "required missing",
+			env:          map[string]string{},
+			wantProblems: []string{"TEST_TOKEN is required"},
+		},
+		{
+			name: "bad values",
+			env: map[string]string{
+				"TEST_TIMEOUT": "15",
+				"TEST_WORKERS": "four",
+				"TEST_DEBUG":   "yes please",
+			},
+			wantProblems: []string{
+				"TEST_TOKEN is required",
+				`TEST_TIMEOUT: "15" is not a duration such as 30s`,
+				`TEST_WORKERS: "four" is not an integer`,
+				`TEST_DEBUG: "yes please" is not true or false`,
+			},
+		},
+		{
+			name:         "Validate problems",
+			env:          map[string]string{"TEST_TOKEN": "t0ken", "TEST_WORKERS": "500"},
+			wantProblems: []string{"TEST_WORKERS must be at most 100", "TEST_WORKERS is too many"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var got testConfig
+			err := Load(&got)
+			if tt.wantProblems != nil {
+				var cfgErr *Error
+				if !errors.As(err, &cfgErr) {
+					t.Fatalf("err = %v, want an *Error", err)
+				}
+				if !reflect.DeepEqual(cfgErr.Problems, tt.wantProblems) {
+					t.Errorf("problems = %q, want %q", cfgErr.Problems, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := defaults
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadBadValueKeepsDefault(t *testing.T) {
+	isolate(t)
+	t.Setenv("TEST_TOKEN", "t0ken")
+	t.Setenv("TEST_WORKERS", "four")
+
+	var c testConfig
+	if err := Load(&c); err == nil {
+		t.Fatal("want an error for TEST_WORKERS")
+	}
+	if c.Workers != 4 {
+		t.Errorf("Workers = %d, want the default 4", c.Workers)
+	}
+}
+
+func TestLoadNotAStruct(t *testing.T) {
+	var c testConfig
+	for _, dst := range []interface{}{c, new(string), nil} {
+		if err := Load(dst); err == nil || !strings.Contains(err.Error(), "pointer to a struct") {
+			t.Errorf("Load(%T) = %v, want a pointer error", dst, err)
+		}
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // YAML for CONFIG_FILE
+		dotenv  string // contents of .env
+		env     map[string]string
+		want    func(c *testConfig)
+		wantErr string
+	}{
+		{
+			name: "YAML file",
+			file: "test_token: from-file\nTEST_PORT: 9000\ntest_origins:\n  - https://a.example\n  - https://b.example\ntest_debug: true\ntest_key:\n",
+			want: func(c *testConfig) {
+				c.Token = "from-file"
+				c.Port = "9000"
+				c.Origins = []string{"https://a.example", "https://b.example"}
+				c.Debug = true
+			},
+		},
+		{
+			name: "environment beats the file",
+			file: "TEST_TOKEN: from-file\nTEST_PORT: 9000\n",
+			env:  map[string]string{"TEST_PORT": "9100"},
+			want: func(c *testConfig) {
+				c.Token = "from-file"
+				c.Port = "9100"
+			},
+		},
+		{
+			name:   ".env beats the file",
+			file:   "TEST_TOKEN: from-file\nTEST_PORT: 9000\n",
+			dotenv: "TEST_PORT=9200\n",
+			want: func(c *testConfig) {
+				c.Token = "from-file"
+				c.Port = "9200"
+			},
+		},
+		{
+			name:   "environment beats .env",
+			dotenv: "TEST_TOKEN=from-dotenv\nTEST_PORT=9200\n",
+			env:    map[string]string{"TEST_PORT": "9300"},
+			want: func(c *testConfig) {
+				c.Token = "from-dotenv"
+				c.Port = "9300"
+			},
+		},
+		{
+			name:    "nested settings",
+			file:    "database:\n  url: postgres://db\n",
+			wantErr: "nested settings are not supported",
+		},
+		{
+			name:    "not YAML",
+			file:    "TEST_PORT: [9000\n",
+			wantErr: FileVar + ":",
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{FileVar: "does-not-exist.yaml"},
+			wantErr: "does-not-exist.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			if tt.file != "" {
+				path := filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv(FileVar, path)
+			}
+			if tt.dotenv != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.dotenv), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var got testConfig
+			err := Load(&got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want testConfig
+			want.Port = "8081"
+			want.Origins = []string{"http://localhost:3000"}
+			want.Timeout = 15 * time.Second
+			want.Workers = 4
+			want.Database.URL = "postgres://localhost/test"
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	c := testConfig{
+		Token:   "t0ken",
+		Port:    "8081",
+		Origins: []string{"https://a.example", "https://b.example"},
+		Timeout: 90 * time.Second,
+	}
+	c.Database.URL = "postgres://db"
+
+	var buf bytes.Buffer
+	Print(&buf, &c)
+	want := strings.Join([]string{
+		"  TEST_TOKEN=[redacted]",
+		"  TEST_PORT=8081",
+		"  TEST_ORIGINS=https://a.example,https://b.example",
+		"  TEST_TIMEOUT=1m30s",
+		"  TEST_WORKERS=0",
+		"  TEST_DEBUG=false",
+		"  TEST_KEY=",
+		"  TEST_DATABASE_URL=postgres://db",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Print wrote\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(buf.String(), "t0ken") {
+		t.Error("Print leaked a secret")
+	}
+
+	logged := LogValue(c)
+	for _, a := range logged.Group() {
+		if a.Key == "TEST_TOKEN" && a.Value.String() != "[redacted]" {
+			t.Errorf("LogValue TEST_TOKEN = %q, want [redacted]", a.Value.String())
+		}
+	}
+	if len(logged.Group()) != 8 {
+		t.Errorf("LogValue has %d settings, want 8", len(logged.Group()))
+	}
+}
@@ -8,3 +8,5 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 )
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,86 @@
+package analytics
+
+// Category is a head-to-head scoring category
+type Category struct {
+	Key           string `json:"key"`
+	Name          string `json:"name"`
+	LowerIsBetter bool   `json:"lower_is_better"`
+}
+
+// Categories lists the standard nine categories in ESPN display order
+var Categories = []Category{
+	{Key: "fg_pct", Name: "FG%"},
+	{Key: "ft_pct", Name: "FT%"},
+	{Key: "threes", Name: "3PM"},
+	{Key: "rebounds", Name: "REB"},
+	{Key: "assists", Name: "AST"},
+	{Key: "steals", Name: "STL"},
+	{Key: "blocks", Name: "BLK"},
+	{Key: "turnovers", Name: "TO", LowerIsBetter: true},
+	{Key: "points", Name: "PTS"},
+}
+
+// StatLine holds the counting stats behind the nine categories. Values are
+// usually per-game averages but sums of lines are also valid lines.
+type StatLine struct {
+	FGM        float64 `json:"fgm"`
+	FGA        float64 `json:"fga"`
+	FTM        float64 `json:"ftm"`
+	FTA        float64 `json:"fta"`
+	ThreesMade float64 `json:"threes_made"`
+	Rebounds   float64 `json:"rebounds"`
+	Assists    float64 `json:"assists"`
+	Steals     float64 `json:"steals"`
+	Blocks     float64 `json:"blocks"`
+	Turnovers  float64 `json:"turnovers"`
+	Points     float64 `json:"points"`
+}
+
+// Add returns the sum of two stat lines
+func (s StatLine) Add(o StatLine) StatLine {
+	return StatLine{
+		FGM:        s.FGM + o.FGM,
+		FGA:        s.FGA + o.FGA,
+		FTM:        s.FTM + o.FTM,
+		FTA:        s.FTA + o.FTA,
+		ThreesMade: s.ThreesMade + o.ThreesMade,
+		Rebounds:   s.Rebounds + o.Rebounds,
+		Assists:    s.Assists + o.Assists,
+		Steals:     s.Steals + o.Steals,
+		Blocks:     s.Blocks + o.Blocks,
+		Turnovers:  s.Turnovers + o.Turnovers,
+		Points:     s.Points + o.Points,
+	}
+}
+
+// Value returns the line's value for a category key. Percentages are
+// computed from makes and attempts so they aggregate correctly.
+func (s StatLine) Value(key string) float64 {
+	switch key {
+	case "fg_pct":
+		if s.FGA == 0 {
+			return 0
+		}
+		return s.FGM / s.FGA
+	case "ft_pct":
+		if s.FTA == 0 {
+			return 0
+		}
+		return s.FTM / s.FTA
+	case "threes":
+		return s.ThreesMade
+	case "rebounds":
+		return s.Rebounds
+	case "assists":
+		return s.Assists
+	case "steals":
+		return s.Steals
+	case "blocks":
+		return s.Blocks
+	case "turnovers":
+		return s.Turnovers
+	case "points":
+		return s.Points
+	}
+	return 0
+}
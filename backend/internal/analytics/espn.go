@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// StatLineFromESPN converts an ESPN averageStats map into a StatLine
+func StatLineFromESPN(avg map[string]float64) StatLine {
+	return StatLine{
+		FGM:        avg[espn.StatFGM],
+		FGA:        avg[espn.StatFGA],
+		FTM:        avg[espn.StatFTM],
+		FTA:        avg[espn.StatFTA],
+		ThreesMade: avg[espn.StatThreesMade],
+		Rebounds:   avg[espn.StatRebounds],
+		Assists:    avg[espn.StatAssists],
+		Steals:     avg[espn.StatSteals],
+		Blocks:     avg[espn.StatBlocks],
+		Turnovers:  avg[espn.StatTurnovers],
+		Points:     avg[espn.StatPoints],
+	}
+}
+
+// RosterFromESPN builds a Roster from an ESPN team using season averages
+func RosterFromESPN(team espn.Team) Roster {
+	roster := Roster{TeamID: team.ID, TeamName: team.DisplayName()}
+	for _, entry := range team.Roster.Entries {
+		p := entry.PlayerPoolEntry.Player
+		avg, _ := p.Averages(espn.SplitSeason)
+		roster.Players = append(roster.Players, RosterPlayer{
+			ID:   p.ID,
+			Name: p.FullName,
+			Line: StatLineFromESPN(avg),
+		})
+	}
+	return roster
+}
+
+// categoryStatIDs maps each category name to its ESPN stat ID
+var categoryStatIDs = map[string]string{
+	"FG%": espn.StatFGPct,
+	"FT%": espn.StatFTPct,
+	"3PM": espn.StatThreesMade,
+	"REB": espn.StatRebounds,
+	"AST": espn.StatAssists,
+	"STL": espn.StatSteals,
+	"BLK": espn.StatBlocks,
+	"TO":  espn.StatTurnovers,
+	"PTS": espn.StatPoints,
+}
+
+// CategoryResultsFromESPN converts both sides' ESPN category scores into
+// results in Categories order, with home as team 1. Categories ESPN did not
+// score are skipped.
+func CategoryResultsFromESPN(home, away espn.MatchupTeam) []models.CategoryResult {
+	results := make([]models.CategoryResult, 0, len(Categories))
+	for _, c := range Categories {
+		id := categoryStatIDs[c.Name]
+		h, okHome := home.CumulativeScore.ScoreByStat[id]
+		a, okAway := away.CumulativeScore.ScoreByStat[id]
+		if !okHome || !okAway {
+			continue
+		}
+		results = append(results, models.CategoryResult{Category: c.Name, Team1: h.Score, Team2: a.Score})
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+package analytics
+
+import "github.com/milindkumar1/swishradar/internal/models"
+
+// PredictMatchup projects a matchup from each roster's per-game season
+// averages, summed over its players, with home as team 1. It returns the
+// projected value of every category and the favoured side: 1, 2, or 0 when
+// the categories split evenly.
+func PredictMatchup(home, away Roster) ([]models.CategoryResult, int) {
+	homeLine, awayLine := rosterTotal(home), rosterTotal(away)
+
+	results := make([]models.CategoryResult, 0, len(Categories))
+	var homeWins, awayWins int
+	for _, c := range Categories {
+		h, a := homeLine.Value(c.Key), awayLine.Value(c.Key)
+		results = append(results, models.CategoryResult{Category: c.Name, Team1: h, Team2: a})
+
+		if c.LowerIsBetter {
+			h, a = a, h
+		}
+		switch {
+		case h > a:
+			homeWins++
+		case a > h:
+			awayWins++
+		}
+	}
+
+	switch {
+	case homeWins > awayWins:
+		return results, 1
+	case awayWins > homeWins:
+		return results, 2
+	}
+	return results, 0
+}
+
+func rosterTotal(r Roster) StatLine {
+	var total StatLine
+	for _, p := range r.Players {
+		total = total.Add(p.Line)
+	}
+	return total
+}
@@ -0,0 +1,45 @@
+package analytics
+
+import "testing"
+
+func TestPredictMatchup(t *testing.T) {
+	guard := RosterPlayer{Line: StatLine{FGM: 8, FGA: 18, FTM: 5, FTA: 6, ThreesMade: 3, Rebounds: 4, Assists: 8, Steals: 1.5, Blocks: 0.3, Turnovers: 3, Points: 24}}
+	big := RosterPlayer{Line: StatLine{FGM: 9, FGA: 15, FTM: 3, FTA: 5, ThreesMade: 0.5, Rebounds: 12, Assists: 2, Steals: 0.8, Blocks: 2, Turnovers: 2, Points: 21.5}}
+
+	tests := []struct {
+		name       string
+		home, away Roster
+		want       int
+	}{
+		{"deeper home roster", Roster{Players: []RosterPlayer{guard, big}}, Roster{Players: []RosterPlayer{guard}}, 1},
+		{"deeper away roster", Roster{Players: []RosterPlayer{big}}, Roster{Players: []RosterPlayer{guard, big}}, 2},
+		{"identical rosters", Roster{Players: []RosterPlayer{guard}}, Roster{Players: []RosterPlayer{guard}}, 0},
+		{"empty rosters", Roster{}, Roster{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, favoured := PredictMatchup(tt.home, tt.away)
+			if favoured != tt.want {
+				t.Errorf("favoured = %d, want %d", favoured, tt.want)
+			}
+			if len(results) != len(Categories) {
+				t.Fatalf("got %d categories, want %d", len(results), len(Categories))
+			}
+			for i, c := range Categories {
+				if results[i].Category != c.Name {
+					t.Errorf("category %d = %s, want %s", i, results[i].Category, c.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestPredictMatchupTurnovers(t *testing.T) {
+	// Identical but for turnovers, where fewer wins
+	careful := Roster{Players: []RosterPlayer{{Line: StatLine{Points: 20, Turnovers: 1}}}}
+	sloppy := Roster{Players: []RosterPlayer{{Line: StatLine{Points: 20, Turnovers: 5}}}}
+
+	if _, favoured := PredictMatchup(careful, sloppy); favoured != 1 {
+		t.Errorf("favoured = %d, want the home team with fewer turnovers", favoured)
+	}
+}
@@ -0,0 +1,175 @@
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// CategoryOutcome is a single category's result. Winner is the winning
+// team's ID, or 0 for a tie.
+type CategoryOutcome struct {
+	Category string  `json:"category"`
+	Team1    float64 `json:"team1"`
+	Team2    float64 `json:"team2"`
+	Winner   int     `json:"winner"`
+	Margin   float64 `json:"margin"` // relative to the larger value, 0-1
+}
+
+// RecapTeam is one side of a matchup recap
+type RecapTeam struct {
+	ID            int             `json:"id"`
+	Name          string          `json:"name"`
+	CategoriesWon int             `json:"categories_won"`
+	MVP           *models.TeamMVP `json:"mvp,omitempty"`
+}
+
+// MatchupRecap summarizes a finished matchup and how it compared with the
+// pre-week prediction
+type MatchupRecap struct {
+	MatchupID           int               `json:"matchup_id"`
+	Team1               RecapTeam         `json:"team1"`
+	Team2               RecapTeam         `json:"team2"`
+	Categories          []CategoryOutcome `json:"categories"`
+	ClosestCategory     *CategoryOutcome  `json:"closest_category,omitempty"`
+	WinnerID            int               `json:"winner_id"`
+	PredictedWinnerID   *int              `json:"predicted_winner_id,omitempty"`
+	PredictionCorrect   *bool             `json:"prediction_correct,omitempty"`
+	PredictedCategories []CategoryOutcome `json:"predicted_categories,omitempty"`
+	CategoriesCalled    int               `json:"categories_called"`
+}
+
+// WeekRecap collects every matchup recap for a scoring period
+type WeekRecap struct {
+	Season         int            `json:"season"`
+	Week           int            `json:"week"`
+	PeriodStart    *time.Time     `json:"period_start,omitempty"`
+	PeriodEnd      *time.Time     `json:"period_end,omitempty"`
+	Matchups       []MatchupRecap `json:"matchups"`
+	BiggestBlowout *int           `json:"biggest_blowout_matchup_id,omitempty"`
+}
+
+// RecapMatchup scores each category and compares the result with the stored
+// prediction. MVPs are filled in by the caller.
+func RecapMatchup(m models.Matchup) MatchupRecap {
+	recap := MatchupRecap{
+		MatchupID:         m.ID,
+		Team1:             RecapTeam{ID: m.Team1ID, Name: m.Team1Name},
+		Team2:             RecapTeam{ID: m.Team2ID, Name: m.Team2Name},
+		PredictedWinnerID: m.PredictedWinner,
+	}
+
+	recap.Categories = outcomes(m, m.CategoryResults)
+	for i, c := range recap.Categories {
+		switch c.Winner {
+		case m.Team1ID:
+			recap.Team1.CategoriesWon++
+		case m.Team2ID:
+			recap.Team2.CategoriesWon++
+		}
+		if recap.ClosestCategory == nil || c.Margin < recap.ClosestCategory.Margin {
+			recap.ClosestCategory = &recap.Categories[i]
+		}
+	}
+
+	switch {
+	case recap.Team1.CategoriesWon > recap.Team2.CategoriesWon:
+		recap.WinnerID = m.Team1ID
+	case recap.Team2.CategoriesWon > recap.Team1.CategoriesWon:
+		recap.WinnerID = m.Team2ID
+	}
+	if recap.WinnerID == 0 && m.ActualWinner != nil {
+		recap.WinnerID = *m.ActualWinner
+	}
+
+	if m.PredictedWinner != nil {
+		correct := *m.PredictedWinner == recap.WinnerID
+		recap.PredictionCorrect = &correct
+	}
+
+	if len(m.PredictedCategories) > 0 {
+		recap.PredictedCategories = outcomes(m, m.PredictedCategories)
+		actual := make(map[string]int, len(recap.Categories))
+		for _, c := range recap.Categories {
+			actual[c.Category] = c.Winner
+		}
+		for _, p := range recap.PredictedCategories {
+			if winner, ok := actual[p.Category]; ok && winner == p.Winner {
+				recap.CategoriesCalled++
+			}
+		}
+	}
+
+	return recap
+}
+
+// BuildWeekRecap recaps every matchup of a period and picks the biggest
+// blowout: the widest category margin, then the widest average margin
+func BuildWeekRecap(matchups []models.Matchup) WeekRecap {
+	var week WeekRecap
+	if len(matchups) == 0 {
+		return week
+	}
+	week.Season = matchups[0].Season
+	week.Week = matchups[0].Week
+	week.PeriodStart = matchups[0].PeriodStart
+	week.PeriodEnd = matchups[0].PeriodEnd
+
+	bestDiff, bestMargin := -1, -1.0
+	for _, m := range matchups {
+		recap := RecapMatchup(m)
+		week.Matchups = append(week.Matchups, recap)
+
+		diff := recap.Team1.CategoriesWon - recap.Team2.CategoriesWon
+		if diff < 0 {
+			diff = -diff
+		}
+		var margin float64
+		for _, c := range recap.Categories {
+			margin += c.Margin
+		}
+		if len(recap.Categories) > 0 {
+			margin /= float64(len(recap.Categories))
+		}
+
+		if diff > bestDiff || (diff == bestDiff && margin > bestMargin) {
+			bestDiff, bestMargin = diff, margin
+			id := recap.MatchupID
+			week.BiggestBlowout = &id
+		}
+	}
+
+	return week
+}
+
+func outcomes(m models.Matchup, results []models.CategoryResult) []CategoryOutcome {
+	lowerIsBetter := make(map[string]bool, len(Categories))
+	for _, c := range Categories {
+		lowerIsBetter[c.Name] = c.LowerIsBetter
+	}
+
+	out := make([]CategoryOutcome, 0, len(results))
+	for _, r := range results {
+		o := CategoryOutcome{Category: r.Category, Team1: r.Team1, Team2: r.Team2}
+
+		team1Ahead := r.Team1 > r.Team2
+		if lowerIsBetter[r.Category] {
+			team1Ahead = r.Team1 < r.Team2
+		}
+		switch {
+		case r.Team1 == r.Team2:
+			o.Winner = 0
+		case team1Ahead:
+			o.Winner = m.Team1ID
+		default:
+			o.Winner = m.Team2ID
+		}
+
+		if larger := math.Max(math.Abs(r.Team1), math.Abs(r.Team2)); larger > 0 {
+			o.Margin = math.Abs(r.Team1-r.Team2) / larger
+		}
+		out = append(out, o)
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package analytics
+
+// RosterPlayer is a rostered player with the stat line used for evaluation
+type RosterPlayer struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Line StatLine `json:"line"`
+}
+
+// Roster is a fantasy team and its players
+type Roster struct {
+	TeamID   int            `json:"team_id"`
+	TeamName string         `json:"team_name"`
+	Players  []RosterPlayer `json:"players"`
+}
+
+// CategoryChange compares a team's category value before and after a trade
+type CategoryChange struct {
+	Category string  `json:"category"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
+	Change   float64 `json:"change"`
+	Improved bool    `json:"improved"`
+}
+
+// TradeSide is one team's view of a trade
+type TradeSide struct {
+	TeamID     int              `json:"team_id"`
+	TeamName   string           `json:"team_name"`
+	Sends      []string         `json:"sends"`
+	Receives   []string         `json:"receives"`
+	Categories []CategoryChange `json:"categories"`
+	Gained     int              `json:"categories_gained"`
+	Lost       int              `json:"categories_lost"`
+}
+
+// TradeAnalysis is the result of evaluating a two-team trade
+type TradeAnalysis struct {
+	Teams []TradeSide `json:"teams"`
+}
+
+// EvaluateTrade compares per-game category production for both teams before
+// and after team a sends fromA to team b in exchange for fromB. Player IDs
+// not found on the respective roster are ignored.
+func EvaluateTrade(a, b Roster, fromA, fromB []int) TradeAnalysis {
+	return TradeAnalysis{
+		Teams: []TradeSide{
+			evaluateSide(a, b, fromA, fromB),
+			evaluateSide(b, a, fromB, fromA),
+		},
+	}
+}
+
+func evaluateSide(team, partner Roster, sends, receives []int) TradeSide {
+	sendSet := idSet(sends)
+	receiveSet := idSet(receives)
+
+	side := TradeSide{TeamID: team.TeamID, TeamName: team.TeamName}
+
+	var before, after StatLine
+	for _, p := range team.Players {
+		before = before.Add(p.Line)
+		if sendSet[p.ID] {
+			side.Sends = append(side.Sends, p.Name)
+			continue
+		}
+		after = after.Add(p.Line)
+	}
+	for _, p := range partner.Players {
+		if receiveSet[p.ID] {
+			side.Receives = append(side.Receives, p.Name)
+			after = after.Add(p.Line)
+		}
+	}
+
+	for _, cat := range Categories {
+		change := CategoryChange{
+			Category: cat.Name,
+			Before:   before.Value(cat.Key),
+			After:    after.Value(cat.Key),
+		}
+		change.Change = change.After - change.Before
+		if cat.LowerIsBetter {
+			change.Improved = change.Change < 0
+		} else {
+			change.Improved = change.Change > 0
+		}
+
+		if change.Improved {
+			side.Gained++
+		} else if change.Change != 0 {
+			side.Lost++
+		}
+		side.Categories = append(side.Categories, change)
+	}
+
+	return side
+}
+
+func idSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"sort"
+
+	"github.com/milindkumar1/swishradar/internal/espn"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// TransactionGrade rates one team's side of a transaction by the recent
+// fantasy value per game it gained and gave up
+type TransactionGrade struct {
+	TeamESPNID int     `json:"team_espn_id"`
+	TeamName   string  `json:"team_name"`
+	Gained     float64 `json:"gained"`
+	Lost       float64 `json:"lost"`
+	Net        float64 `json:"net"`
+	Grade      string  `json:"grade"`
+}
+
+// gradeCutoffs are the minimum net value per game for each letter grade
+var gradeCutoffs = []struct {
+	min   float64
+	grade string
+}{
+	{8, "A"},
+	{3, "B"},
+	{-3, "C"},
+	{-8, "D"},
+}
+
+// letterGrade converts a net value per game into a letter grade
+func letterGrade(net float64) string {
+	for _, c := range gradeCutoffs {
+		if net >= c.min {
+			return c.grade
+		}
+	}
+	return "F"
+}
+
+// GradeTransaction grades every team that acquired a player in t. values
+// holds recent fantasy value per game by ESPN player ID; players without
+// recent games count as zero. Teams that only dropped players are not graded.
+func GradeTransaction(t models.Transaction, values map[int]float64) []TransactionGrade {
+	grades := make(map[int]*TransactionGrade)
+	team := func(id int, name string) *TransactionGrade {
+		g, ok := grades[id]
+		if !ok {
+			g = &TransactionGrade{TeamESPNID: id, TeamName: name}
+			grades[id] = g
+		}
+		return g
+	}
+
+	acquired := make(map[int]bool)
+	for _, m := range t.Moves {
+		value := values[m.PlayerESPNID]
+		switch m.Action {
+		case espn.ActionAdd:
+			team(m.TeamESPNID, m.TeamName).Gained += value
+			acquired[m.TeamESPNID] = true
+		case espn.ActionDrop:
+			team(m.TeamESPNID, m.TeamName).Lost += value
+		case espn.ActionTrade:
+			team(m.TeamESPNID, m.TeamName).Gained += value
+			team(m.FromTeamESPNID, m.FromTeamName).Lost += value
+			acquired[m.TeamESPNID] = true
+		}
+	}
+
+	out := make([]TransactionGrade, 0, len(acquired))
+	for id := range acquired {
+		g := grades[id]
+		g.Net = g.Gained - g.Lost
+		g.Grade = letterGrade(g.Net)
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Net > out[j].Net })
+	return out
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// sign builds a JWT from header and claims, signed with HS256 and secret
+func sign(t *testing.T, secret string, header, claims map[string]interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	// claims returns a valid set of claims with overrides applied; a nil
+	// override removes the claim
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "user-1",
+			"email": "kd@example.com",
+			"aud":   "authenticated",
+			"exp":   now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	valid := sign(t, testSecret, hs256, claims(nil))
+	none := sign(t, testSecret, map[string]interface{}{"alg": "none"}, claims(nil))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", valid, ""},
+		{"alg none", none, "unsupported algorithm"},
+		{"alg none unsigned", none[:strings.LastIndex(none, ".")+1], "unsupported algorithm"},
+		{"alg RS256", sign(t, testSecret, map[string]interface{}{"alg": "RS256"}, claims(nil)), "unsupported algorithm"},
+		{"no alg", sign(t, testSecret, map[string]interface{}{}, claims(nil)), "unsupported algorithm"},
+		{"wrong secret", sign(t, "other-secret", hs256, claims(nil)), "signature mismatch"},
+		{"tampered claims", tamper(t, valid), "signature mismatch"},
+		{"bad signature encoding", valid[:strings.LastIndex(valid, ".")+1] + "!!!", "bad signature encoding"},
+		{"two segments", valid[:strings.LastIndex(valid, ".")], "malformed"},
+		{"four segments", valid + ".x", "malformed"},
+		{"empty", "", "malformed"},
+
+		{"no exp", sign(t, testSecret, hs256, claims(map[string]interface{}{"exp": nil})), "expired"},
+		{"expired", sign(t, testSecret, hs256, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), "expired"},
+		{"exp just inside the skew", sign(t, testSecret, hs256, claims(map[string]interface{}{"exp": now.Add(-clockSkew + time.Second).Unix()})), ""},
+		{"exp at the skew", sign(t, testSecret, hs256, claims(map[string]interface{}{"exp": now.Add(-clockSkew).Unix()})), "expired"},
+		{"nbf in the past", sign(t, testSecret, hs256, claims(map[string]interface{}{"nbf": now.Add(-time.Hour).Unix()})), ""},
+		{"nbf at the skew", sign(t, testSecret, hs256, claims(map[string]interface{}{"nbf": now.Add(clockSkew).Unix()})), ""},
+		{"nbf just past the skew", sign(t, testSecret, hs256, claims(map[string]interface{}{"nbf": now.Add(clockSkew + time.Second).Unix()})), "not valid yet"},
+
+		{"aud array", sign(t, testSecret, hs256, claims(map[string]interface{}{"aud": []string{"other", "authenticated"}})), ""},
+		{"aud array without ours", sign(t, testSecret, hs256, claims(map[string]interface{}{"aud": []string{"other"}})), "wrong audience"},
+		{"aud wrong string", sign(t, testSecret, hs256, claims(map[string]interface{}{"aud": "anon"})), "wrong audience"},
+		{"no aud", sign(t, testSecret, hs256, claims(map[string]interface{}{"aud": nil})), "wrong audience"},
+		{"aud not a string", sign(t, testSecret, hs256, claims(map[string]interface{}{"aud": 7})), "bad claims"},
+
+		{"no sub", sign(t, testSecret, hs256, claims(map[string]interface{}{"sub": nil})), "missing subject"},
+		{"empty sub", sign(t, testSecret, hs256, claims(map[string]interface{}{"sub": ""})), "missing subject"},
+	}
+
+	v := NewVerifier(testSecret, "authenticated")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token, now)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want ErrInvalidToken about %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Subject != "user-1" || got.Email != "kd@example.com" {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutAudience(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	token := sign(t, testSecret, map[string]interface{}{"alg": "HS256"}, map[string]interface{}{
+		"sub": "user-1",
+		"exp": now.Add(time.Hour).Unix(),
+	})
+	if _, err := NewVerifier(testSecret, "").Verify(token, now); err != nil {
+		t.Errorf("a verifier without an audience should accept tokens without aud: %v", err)
+	}
+}
+
+// tamper swaps the claims of token for different ones, keeping the original
+// signature
+func tamper(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	forged := sign(t, testSecret, nil, map[string]interface{}{"sub": "admin"})
+	parts[1] = strings.Split(forged, ".")[1]
+	return strings.Join(parts, ".")
+}
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullName string
+		nick     string
+		email    string
+		want     string
+	}{
+		{"full name", "Kevin Durant", "KD", "kd@example.com", "Kevin Durant"},
+		{"name", "", "KD", "kd@example.com", "KD"},
+		{"email", "", "", "kd@example.com", "kd"},
+		{"nothing", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		var c Claims
+		c.UserMetadata.FullName = tt.fullName
+		c.UserMetadata.Name = tt.nick
+		c.Email = tt.email
+		if got := c.DisplayName(); got != tt.want {
+			t.Errorf("%s: DisplayName = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TokenPrefix marks opaque API tokens so they can be told apart from JWTs
+const TokenPrefix = "sr_"
+
+// NewAPIToken generates a random API token. Only its hash is stored; the
+// token itself is shown once when it is created.
+func NewAPIToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the hex SHA-256 of token, as stored in api_tokens
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken reports whether token looks like one from NewAPIToken
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+)
+
+// refreshTimeout bounds background revalidation, which is detached from the
+// request that triggered it
+const refreshTimeout = 30 * time.Second
+
+// lookups counts cache reads by result so the hit ratio can be graphed.
+// cache is "response" for cached routes and "value" for Fetch.
+var lookups = metrics.Default.NewCounterVec("swishradar_cache_lookups_total",
+	"Response cache lookups by result: hit, stale or miss.", "cache", "result")
+
+// Policy controls how long a cached response is used
+type Policy struct {
+	// TTL is how long a response is served without revalidation
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after TTL a stale response is still
+	// served while a fresh one is fetched in the background
+	StaleWhileRevalidate time.Duration
+}
+
+// Cache serves responses from a Store, refreshing stale entries in the
+// background so callers rarely wait on the upstream
+type Cache struct {
+	store Store
+	now   func() time.Time
+
+	// refreshing holds keys with a background refresh in progress
+	refreshing sync.Map
+}
+
+// New creates a cache on top of store
+func New(store Store) *Cache {
+	return &Cache{store: store, now: time.Now}
+}
+
+// Purge removes every cached entry whose key starts with prefix. Keys for
+// cached routes are the request path plus sorted query, so "/api/espn/"
+// purges all ESPN routes.
+func (c *Cache) Purge(ctx context.Context, prefix string) (int, error) {
+	return c.store.Purge(ctx, prefix)
+}
+
+// Handler caches successful GET responses from next under policy. Fresh
+// entries are served directly, stale ones are served while a refresh runs
+// in the background, and If-None-Match is answered with 304 when the ETag
+// still matches. The refresh replays the request after it has finished, so
+// next must not read router state such as chi's URL parameters; anything
+// it needs has to be in the request's URL or in context values.
+func (c *Cache) Handler(policy Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := requestKey(r)
+		now := c.now()
+		entry, err := c.get(r.Context(), key, now)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error reading cache", "key", key, "error", err)
+		}
+
+		switch {
+		case entry != nil && entry.Fresh(now):
+			lookups.Inc("response", "hit")
+			serveEntry(w, r, entry, "HIT", now)
+		case entry != nil:
+			lookups.Inc("response", "stale")
+			c.refreshInBackground(r.Context(), key, func(ctx context.Context) {
+				rec := newRecorder()
+				next.ServeHTTP(rec, r.Clone(ctx))
+				if rec.status == http.StatusOK {
+					c.set(ctx, key, rec.entry(policy, c.now()))
+				}
+			})
+			serveEntry(w, r, entry, "STALE", now)
+		default:
+			lookups.Inc("response", "miss")
+			rec := newRecorder()
+			next.ServeHTTP(rec, r)
+			if rec.status != http.StatusOK {
+				rec.writeTo(w)
+				return
+			}
+			entry = rec.entry(policy, now)
+			c.set(r.Context(), key, entry)
+			serveEntry(w, r, entry, "MISS", now)
+		}
+	})
+}
+
+// Fetch returns the value cached under key, calling fn on a miss. Values are
+// stored as JSON so any Store works. A stale value is returned immediately
+// while fn refreshes it in the background with a context of its own.
+func Fetch[T any](ctx context.Context, c *Cache, key string, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+
+	now := c.now()
+	entry, err := c.get(ctx, key, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "error reading cache", "key", key, "error", err)
+	}
+	if entry != nil && json.Unmarshal(entry.Body, &value) == nil {
+		if entry.Fresh(now) {
+			lookups.Inc("value", "hit")
+		} else {
+			lookups.Inc("value", "stale")
+			c.refreshInBackground(ctx, key, func(ctx context.Context) {
+				if fresh, err := fn(ctx); err == nil {
+					c.storeValue(ctx, key, policy, fresh)
+				} else {
+					slog.ErrorContext(ctx, "error refreshing cache", "error", err)
+				}
+			})
+		}
+		return value, nil
+	}
+
+	lookups.Inc("value", "miss")
+	value, err = fn(ctx)
+	if err != nil {
+		return value, err
+	}
+	c.storeValue(ctx, key, policy, value)
+	return value, nil
+}
+
+func (c *Cache) storeValue(ctx context.Context, key string, policy Policy, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding cache value", "key", key, "error", err)
+		return
+	}
+	c.set(ctx, key, newEntry(http.StatusOK, http.Header{"Content-Type": {"application/json"}}, body, policy, c.now()))
+}
+
+// get reads key from the store, treating an entry past its ExpiresAt at
+// now as missing even if the store still has it
+func (c *Cache) get(ctx context.Context, key string, now time.Time) (*Entry, error) {
+	entry, err := c.store.Get(ctx, key)
+	if entry != nil && !now.Before(entry.ExpiresAt) {
+		return nil, err
+	}
+	return entry, err
+}
+
+func (c *Cache) set(ctx context.Context, key string, e *Entry) {
+	if err := c.store.Set(ctx, key, e); err != nil {
+		slog.ErrorContext(ctx, "error writing cache", "key", key, "error", err)
+	}
+}
+
+// refreshInBackground runs refresh unless one is already running for key.
+// It gets the values of parent, such as the league a handler is scoped to,
+// but not its cancellation, since it outlives the request. Its logs carry
+// the key being refreshed.
+func (c *Cache) refreshInBackground(parent context.Context, key string, refresh func(ctx context.Context)) {
+	if _, running := c.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer c.refreshing.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), refreshTimeout)
+		defer cancel()
+		refresh(logging.With(ctx, "refresh_key", key))
+	}()
+}
+
+// requestKey identifies a request by path and query, with query parameters
+// sorted so equivalent URLs share an entry
+func requestKey(r *http.Request) string {
+	query := r.URL.Query().Encode()
+	if query == "" {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + query
+}
+
+func newEntry(status int, header http.Header, body []byte, policy Policy, now time.Time) *Entry {
+	sum := sha256.Sum256(body)
+	return &Entry{
+		Status:     status,
+		Header:     header,
+		Body:       body,
+		ETag:       `"` + hex.EncodeToString(sum[:16]) + `"`,
+		StoredAt:   now,
+		FreshUntil: now.Add(policy.TTL),
+		ExpiresAt:  now.Add(policy.TTL + policy.StaleWhileRevalidate),
+	}
+}
+
+func serveEntry(w http.ResponseWriter, r *http.Request, e *Entry, state string, now time.Time) {
+	h := w.Header()
+	for k, v := range e.Header {
+		h[k] = v
+	}
+	h.Set("ETag", e.ETag)
+	h.Set("X-Cache", state)
+	h.Set("Age", strconv.Itoa(int(now.Sub(e.StoredAt).Seconds())))
+
+	maxAge := int(e.FreshUntil.Sub(now).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	h.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+
+	if etagMatches(r.Header.Get("If-None-Match"), e.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(e.Status)
+	if r.Method != http.MethodHead {
+		w.Write(e.Body)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder buffers a handler's response so it can be cached before being
+// written to the client
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+// entry converts the recorded response to a cache entry, keeping only the
+// content headers; upstream CORS and hop-by-hop headers are not replayed
+func (r *recorder) entry(policy Policy, now time.Time) *Entry {
+	header := make(http.Header)
+	if ct := r.header.Get("Content-Type"); ct != "" {
+		header.Set("Content-Type", ct)
+	}
+	return newEntry(r.status, header, r.body.Bytes(), policy, now)
+}
+
+// writeTo passes an uncached response through unchanged
+func (r *recorder) writeTo(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range r.header {
+		h[k] = v
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counting answers with how many times it has been called
+func counting(calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "call %d", calls.Add(1))
+	})
+}
+
+// clock is a Cache clock that only moves when a test advances it
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// fakeClock sets c's clock to one starting at the real time, so stores
+// that expire entries by the real time still agree with it
+func fakeClock(c *Cache) *clock {
+	clk := &clock{t: time.Now()}
+	c.now = clk.now
+	return clk
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestHandlerHitStaleMiss(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		wait   time.Duration
+		want   []string // X-Cache of two requests in a row
+		bodies []string
+	}{
+		{
+			name:   "fresh",
+			policy: Policy{TTL: time.Minute},
+			want:   []string{"MISS", "HIT"},
+			bodies: []string{"call 1", "call 1"},
+		},
+		{
+			name:   "stale",
+			policy: Policy{TTL: time.Minute, StaleWhileRevalidate: time.Hour},
+			wait:   2 * time.Minute,
+			want:   []string{"MISS", "STALE"},
+			bodies: []string{"call 1", "call 1"},
+		},
+		{
+			name:   "expired",
+			policy: Policy{TTL: time.Minute},
+			wait:   2 * time.Minute,
+			want:   []string{"MISS", "MISS"},
+			bodies: []string{"call 1", "call 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := New(NewMemoryStore(10))
+			clk := fakeClock(c)
+			srv := httptest.NewServer(c.Handler(tt.policy, counting(&calls)))
+			defer srv.Close()
+
+			for i := range tt.want {
+				if i > 0 {
+					clk.advance(tt.wait)
+				}
+				resp, body := get(t, srv.URL+"/standings", nil)
+				if got := resp.Header.Get("X-Cache"); got != tt.want[i] {
+					t.Errorf("request %d: X-Cache = %q, want %q", i+1, got, tt.want[i])
+				}
+				if body != tt.bodies[i] {
+					t.Errorf("request %d: body = %q, want %q", i+1, body, tt.bodies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerRefreshesStaleEntry(t *testing.T) {
+	var calls atomic.Int32
+	c := New(NewMemoryStore(10))
+	clk := fakeClock(c)
+	srv := httptest.NewServer(c.Handler(Policy{TTL: time.Minute, StaleWhileRevalidate: time.Hour}, counting(&calls)))
+	defer srv.Close()
+
+	get(t, srv.URL+"/standings", nil)
+	clk.advance(2 * time.Minute)
+	get(t, srv.URL+"/standings", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for {
+		if _, body := get(t, srv.URL+"/standings", nil); body != "call 1" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed entry was not stored")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandlerQueryOrder(t *testing.T) {
+	var calls atomic.Int32
+	c := New(NewMemoryStore(10))
+	srv := httptest.NewServer(c.Handler(Policy{TTL: time.Minute}, counting(&calls)))
+	defer srv.Close()
+
+	get(t, srv.URL+"/free-agents?limit=10&position=PG", nil)
+	resp, _ := get(t, srv.URL+"/free-agents?position=PG&limit=10", nil)
+	if got := resp.Header.Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT for reordered query", got)
+	}
+}
+
+func TestHandlerSkipsErrors(t *testing.T) {
+	var calls atomic.Int32
+	c := New(NewMemoryStore(10))
+	srv := httptest.NewServer(c.Handler(Policy{TTL: time.Minute}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	})))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, _ := get(t, srv.URL+"/standings", nil)
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler called %d times, want 2", n)
+	}
+}
+
+func TestHandlerNotModified(t *testing.T) {
+	var calls atomic.Int32
+	c := New(NewMemoryStore(10))
+	srv := httptest.NewServer(c.Handler(Policy{TTL: time.Minute}, counting(&calls)))
+	defer srv.Close()
+
+	resp, _ := get(t, srv.URL+"/standings", nil)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("response has no ETag")
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching", etag, http.StatusNotModified},
+		{"weak", "W/" + etag, http.StatusNotModified},
+		{"in list", `"other", ` + etag, http.StatusNotModified},
+		{"wildcard", "*", http.StatusNotModified},
+		{"different", `"other"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, srv.URL+"/standings", http.Header{"If-None-Match": {tt.ifNoneMatch}})
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusNotModified && body != "" {
+				t.Errorf("304 has body %q", body)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	var calls atomic.Int32
+	c := New(NewMemoryStore(10))
+	srv := httptest.NewServer(c.Handler(Policy{TTL: time.Minute}, counting(&calls)))
+	defer srv.Close()
+
+	get(t, srv.URL+"/api/espn/standings", nil)
+	get(t, srv.URL+"/api/espn/teams", nil)
+	get(t, srv.URL+"/api/v1/players", nil)
+
+	n, err := c.Purge(context.Background(), "/api/espn/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Purge removed %d entries, want 2", n)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/espn/standings", "MISS"},
+		{"/api/espn/teams", "MISS"},
+		{"/api/v1/players", "HIT"},
+	}
+	for _, tt := range tests {
+		resp, _ := get(t, srv.URL+tt.path, nil)
+		if got := resp.Header.Get("X-Cache"); got != tt.want {
+			t.Errorf("%s after purge: X-Cache = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	c := New(NewMemoryStore(10))
+	calls := 0
+	fn := func(ctx context.Context) ([]string, error) {
+		calls++
+		return []string{"a", "b"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := Fetch(context.Background(), c, "players", Policy{TTL: time.Minute}, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+			t.Errorf("Fetch = %v, want [a b]", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PostgresStore keeps entries in the response_cache table so they survive
+// restarts and are shared between API instances
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store backed by the response_cache table
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (*Entry, error) {
+	var e Entry
+	var header []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT status, header, body, etag, stored_at, fresh_until, expires_at
+		FROM response_cache
+		WHERE key = $1 AND expires_at > NOW()`, key).Scan(
+		&e.Status, &header, &e.Body, &e.ETag, &e.StoredAt, &e.FreshUntil, &e.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	if err := json.Unmarshal(header, &e.Header); err != nil {
+		return nil, fmt.Errorf("failed to decode cached headers: %w", err)
+	}
+	return &e, nil
+}
+
+func (s *PostgresStore) Set(ctx context.Context, key string, e *Entry) error {
+	header, err := json.Marshal(e.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached headers: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO response_cache (key, status, header, body, etag, stored_at, fresh_until, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (key) DO UPDATE SET
+			status = EXCLUDED.status,
+			header = EXCLUDED.header,
+			body = EXCLUDED.body,
+			etag = EXCLUDED.etag,
+			stored_at = EXCLUDED.stored_at,
+			fresh_until = EXCLUDED.fresh_until,
+			expires_at = EXCLUDED.expires_at`,
+		key, e.Status, header, e.Body, e.ETag, e.StoredAt, e.FreshUntil, e.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, prefix string) (int, error) {
+	// Escape LIKE wildcards so the prefix is matched literally
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM response_cache WHERE key LIKE $1 || '%'`, escaped)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge cache: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return int(n), nil
+}
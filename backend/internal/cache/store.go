@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response
+type Entry struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	ETag       string      `json:"etag"`
+	StoredAt   time.Time   `json:"stored_at"`
+	FreshUntil time.Time   `json:"fresh_until"`
+	ExpiresAt  time.Time   `json:"expires_at"` // end of the stale-while-revalidate window
+}
+
+// Fresh reports whether the entry can be served without revalidation
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+// Store persists cache entries. Get returns (nil, nil) on a miss or for
+// expired entries.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, key string, e *Entry) error
+	// Purge deletes every key starting with prefix ("" purges everything)
+	// and returns how many were removed
+	Purge(ctx context.Context, prefix string) (int, error)
+}
+
+// MemoryStore keeps entries in process memory, evicting the oldest when it
+// holds more than maxEntries
+type MemoryStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{maxEntries: maxEntries, entries: make(map[string]*Entry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(e.ExpiresAt) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	return e, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = e
+	if len(s.entries) > s.maxEntries {
+		s.evict()
+	}
+	return nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// evict drops expired entries, then the oldest ones until under the limit.
+// Callers must hold s.mu.
+func (s *MemoryStore) evict() {
+	now := time.Now()
+	for key, e := range s.entries {
+		if !now.Before(e.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	if len(s.entries) <= s.maxEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.entries[keys[i]].StoredAt.Before(s.entries[keys[j]].StoredAt)
+	})
+	for _, key := range keys[:len(keys)-s.maxEntries] {
+		delete(s.entries, key)
+	}
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// ErrConflict is returned when a write would clash with an existing row
+var ErrConflict = errors.New("conflict")
+
+// GetUserByAuthID returns the user linked to a Supabase auth account, or
+// ErrNotFound
+func (db *DB) GetUserByAuthID(ctx context.Context, authID string) (*models.User, error) {
+	u, err := db.scanUser(db.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE auth_id = $1`, authID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by auth id: %w", err)
+	}
+	return u, nil
+}
+
+// LinkAuthUser returns the user for a Supabase auth account, creating it on
+// first sign in. An existing user with the same email and no auth account is
+// linked to it. It returns ErrConflict if the email belongs to a different
+// auth account.
+func (db *DB) LinkAuthUser(ctx context.Context, authID, email, displayName string) (*models.User, error) {
+	u, err := db.GetUserByAuthID(ctx, authID)
+	if !errors.Is(err, ErrNotFound) {
+		return u, err
+	}
+
+	u, err = db.scanUser(db.QueryRowContext(ctx, `
+		INSERT INTO users (auth_id, email, display_name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO UPDATE SET auth_id = EXCLUDED.auth_id
+		WHERE users.auth_id IS NULL OR users.auth_id = EXCLUDED.auth_id
+		RETURNING `+userColumns, authID, email, displayName))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to link auth user: %w", err)
+	}
+	return u, nil
+}
+
+// GetUserByAPIToken returns the owner of an unrevoked API token, recording
+// that it was used, or ErrNotFound
+func (db *DB) GetUserByAPIToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	u, err := db.scanUser(db.QueryRowContext(ctx, `
+		WITH used AS (
+			UPDATE api_tokens SET last_used_at = NOW()
+			WHERE token_hash = $1 AND revoked_at IS NULL
+			RETURNING user_id
+		)
+		SELECT `+userColumns+` FROM users WHERE id = (SELECT user_id FROM used)`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by API token: %w", err)
+	}
+	return u, nil
+}
+
+// CreateAPIToken stores the hash of a new API token for a user. It returns
+// ErrNotFound if the user does not exist.
+func (db *DB) CreateAPIToken(ctx context.Context, userID int, name, tokenHash string) (int, error) {
+	var id int
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO api_tokens (user_id, name, token_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id`, userID, name, tokenHash).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to create API token: %w", err)
+	}
+	return id, nil
+}
+
+// RevokeAPIToken stops an API token from being accepted. It returns
+// ErrNotFound if the token does not exist or is already revoked.
+func (db *DB) RevokeAPIToken(ctx context.Context, id int) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE api_tokens SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IsLeagueMember reports whether a user belongs to a league
+func (db *DB) IsLeagueMember(ctx context.Context, leagueID string, userID int) (bool, error) {
+	var member bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM league_members WHERE league_id = $1 AND user_id = $2
+		)`, leagueID, userID).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("failed to check league membership: %w", err)
+	}
+	return member, nil
+}
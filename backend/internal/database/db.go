@@ -1,61 +1,245 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/milindkumar1/swishradar/internal/secrets"
 )
 
 // DB wraps the database connection
 type DB struct {
 	*sql.DB
+
+	// keys encrypts users' ESPN cookies. It is nil when no credential key
+	// is configured, in which case cookies can be read but not stored.
+	keys *secrets.Keyring
 }
 
-// Connect creates a new database connection
-func Connect() (*DB, error) {
-	connStr := os.Getenv("SUPABASE_CONNECTION_STRING")
-	if connStr == "" {
-		// Build connection string from individual parts
-		host := os.Getenv("SUPABASE_HOST")
-		port := os.Getenv("SUPABASE_PORT")
-		user := os.Getenv("SUPABASE_USER")
-		password := os.Getenv("SUPABASE_PASSWORD")
-		dbname := os.Getenv("SUPABASE_DB")
+// Config describes how to reach the database and size its connection pool.
+// The tags name the settings it is loaded from with the config package.
+type Config struct {
+	// ConnString is a full postgres:// URL or key=value string. When set it
+	// takes precedence over Host, Port, User, Password and DBName.
+	ConnString string `env:"SUPABASE_CONNECTION_STRING" secret:"true"`
+	Host       string `env:"SUPABASE_HOST"`
+	Port       string `env:"SUPABASE_PORT"`
+	User       string `env:"SUPABASE_USER"`
+	Password   string `env:"SUPABASE_PASSWORD" secret:"true"`
+	DBName     string `env:"SUPABASE_DB"`
+
+	// SSLMode overrides the connection string's sslmode. Use "disable" for
+	// a local Postgres without TLS.
+	SSLMode string `env:"DB_SSLMODE"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"5"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	// StatementTimeout cancels queries that run longer on the server. Zero
+	// keeps the server's default.
+	StatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" default:"0"`
+	// ConnectTimeout bounds the initial ping
+	ConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT" default:"10s"`
+
+	// CredentialKeys and CredentialKeyFile hold the keys that encrypt users'
+	// ESPN cookies, in the format secrets.ParseKeyring reads
+	CredentialKeys    string `env:"CREDENTIAL_KEYS" secret:"true"`
+	CredentialKeyFile string `env:"CREDENTIAL_KEY_FILE"`
+}
 
-		if host == "" {
-			return nil, fmt.Errorf("database connection info not provided")
+// Validate reports pool settings that can't be used and credential keys
+// that don't parse
+func (cfg Config) Validate() error {
+	var errs []error
+	for _, s := range []struct {
+		key string
+		n   int64
+	}{
+		{"DB_MAX_OPEN_CONNS", int64(cfg.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", int64(cfg.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", int64(cfg.ConnMaxLifetime)},
+		{"DB_CONN_MAX_IDLE_TIME", int64(cfg.ConnMaxIdleTime)},
+		{"DB_STATEMENT_TIMEOUT", int64(cfg.StatementTimeout)},
+		{"DB_CONNECT_TIMEOUT", int64(cfg.ConnectTimeout)},
+	} {
+		if s.n < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", s.key))
 		}
+	}
+	if _, err := secrets.LoadKeyring(cfg.CredentialKeys, cfg.CredentialKeyFile); err != nil {
+		errs = append(errs, fmt.Errorf("invalid credential keys: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// dsn builds the lib/pq connection string. SSLMode and StatementTimeout are
+// applied on top of ConnString; lib/pq sends statement_timeout to the
+// server as a session setting.
+func (cfg Config) dsn() (string, error) {
+	params := map[string]string{}
+	if cfg.SSLMode != "" {
+		params["sslmode"] = cfg.SSLMode
+	}
+	if cfg.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
 
+	if cfg.ConnString == "" {
+		if cfg.Host == "" {
+			return "", fmt.Errorf("database connection info not provided")
+		}
+		port := cfg.Port
 		if port == "" {
 			port = "5432"
 		}
+		if _, ok := params["sslmode"]; !ok {
+			params["sslmode"] = "require"
+		}
+		params["host"], params["port"] = cfg.Host, port
+		params["user"], params["password"], params["dbname"] = cfg.User, cfg.Password, cfg.DBName
 
-		connStr = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=require",
-			host, port, user, password, dbname,
-		)
+		var parts []string
+		for _, k := range []string{"host", "port", "user", "password", "dbname", "sslmode", "statement_timeout"} {
+			if v := params[k]; v != "" {
+				parts = append(parts, k+"="+quoteDSN(v))
+			}
+		}
+		return strings.Join(parts, " "), nil
 	}
 
-	db, err := sql.Open("postgres", connStr)
+	if strings.HasPrefix(cfg.ConnString, "postgres://") || strings.HasPrefix(cfg.ConnString, "postgresql://") {
+		u, err := url.Parse(cfg.ConnString)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection string: %w", err)
+		}
+		q := u.Query()
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+
+	// key=value form: later settings win in lib/pq
+	dsn := cfg.ConnString
+	for _, k := range []string{"sslmode", "statement_timeout"} {
+		if v, ok := params[k]; ok {
+			dsn += " " + k + "=" + quoteDSN(v)
+		}
+	}
+	return dsn, nil
+}
+
+// quoteDSN quotes a key=value connection string value when needed
+func quoteDSN(v string) string {
+	if !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// Open connects to the database described by cfg and checks it is reachable
+func Open(ctx context.Context, cfg Config) (*DB, error) {
+	dsn, err := cfg.dsn()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	// Test the connection
-	if err := db.Ping(); err != nil {
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	keys, err := secrets.LoadKeyring(cfg.CredentialKeys, cfg.CredentialKeyFile)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid credential keys: %w", err)
+	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, keys: keys}, nil
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
+
+// PoolStats is a snapshot of the connection pool
+type PoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"wait_count"`
+	WaitSeconds       float64 `json:"wait_seconds"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// Health is the result of a database health check
+type Health struct {
+	OK        bool      `json:"ok"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Pool      PoolStats `json:"pool"`
+}
+
+// PoolStats returns the connection pool's current counters
+func (db *DB) PoolStats() PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitSeconds:       s.WaitDuration.Seconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
+
+// HealthCheck pings the database and reports the round trip along with the
+// pool stats
+func (db *DB) HealthCheck(ctx context.Context) Health {
+	start := time.Now()
+	err := db.PingContext(ctx)
+	h := Health{
+		OK:        err == nil,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Pool:      db.PoolStats(),
+	}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	return h
+}
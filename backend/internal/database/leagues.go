@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// UpsertLeague creates or renames a league
+func (db *DB) UpsertLeague(ctx context.Context, id string, season int, name string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO leagues (id, season, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			season = EXCLUDED.season,
+			name = EXCLUDED.name,
+			updated_at = NOW()`, id, season, name)
+	if err != nil {
+		return fmt.Errorf("failed to upsert league %s: %w", id, err)
+	}
+	return nil
+}
+
+// UpsertTeam creates or updates a league's team by ESPN team ID, replacing
+// its roster, and returns the team's row ID
+func (db *DB) UpsertTeam(ctx context.Context, leagueID string, espnTeamID int, name string, roster []models.RosterSlot) (int, error) {
+	rosterJSON, err := json.Marshal(roster)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode roster: %w", err)
+	}
+
+	var id int
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO teams (league_id, espn_team_id, team_name, roster_json)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (league_id, espn_team_id) DO UPDATE SET
+			team_name = EXCLUDED.team_name,
+			roster_json = EXCLUDED.roster_json,
+			updated_at = NOW()
+		RETURNING id`, leagueID, espnTeamID, name, rosterJSON).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert team %d: %w", espnTeamID, err)
+	}
+	return id, nil
+}
+
+// AddLeagueMember links a user to a league, creating a placeholder league row
+// if the league has not been synced yet. It returns ErrNotFound if the user
+// does not exist.
+func (db *DB) AddLeagueMember(ctx context.Context, leagueID string, userID, season int) error {
+	return db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO leagues (id, season, name)
+			VALUES ($1, $2, $1)
+			ON CONFLICT (id) DO NOTHING`, leagueID, season)
+		if err != nil {
+			return fmt.Errorf("failed to create league %s: %w", leagueID, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO league_members (league_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, leagueID, userID)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to add league member: %w", err)
+		}
+		return nil
+	})
+}
+
+// usableCredentials matches users whose ESPN cookies are set and have not
+// been rejected by ESPN
+const usableCredentials = `u.espn_swid IS NOT NULL AND u.espn_s2 IS NOT NULL
+		AND u.espn_status IS DISTINCT FROM '` + ESPNStatusExpired + `'`
+
+// GetLeagueCredentials returns the decrypted ESPN cookies of the league
+// member who checked them in most recently, along with that member's user
+// ID, or ErrNotFound if no member has usable cookies. Cookies stored before
+// checks were recorded come last.
+func (db *DB) GetLeagueCredentials(ctx context.Context, leagueID string) (userID int, swid, s2 string, err error) {
+	var sealedSWID, sealedS2 sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT u.id, u.espn_swid, u.espn_s2
+		FROM league_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.league_id = $1 AND `+usableCredentials+`
+		ORDER BY u.espn_checked_at DESC NULLS LAST, u.id
+		LIMIT 1`, leagueID).Scan(&userID, &sealedSWID, &sealedS2)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", "", ErrNotFound
+	}
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to get credentials for league %s: %w", leagueID, err)
+	}
+
+	plainSWID, err := db.openCredential(swidColumn, sealedSWID)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("league %s: %w", leagueID, err)
+	}
+	plainS2, err := db.openCredential(s2Column, sealedS2)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("league %s: %w", leagueID, err)
+	}
+	return userID, *plainSWID, *plainS2, nil
+}
+
+// ListCredentialedLeagues returns the IDs of leagues with at least one member
+// who has ESPN cookies
+func (db *DB) ListCredentialedLeagues(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT m.league_id
+		FROM league_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE `+usableCredentials+`
+		ORDER BY m.league_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leagues: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan league id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListUserLeagues returns the IDs of the leagues a user belongs to
+func (db *DB) ListUserLeagues(ctx context.Context, userID int) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT league_id FROM league_members
+		WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leagues for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan league id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
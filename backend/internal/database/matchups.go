@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+const matchupQuery = `
+	SELECT m.id, m.league_id, m.week, m.season, m.team1_id, m.team2_id,
+	       t1.team_name, t2.team_name, m.team1_score, m.team2_score,
+	       m.predicted_winner, m.actual_winner, m.period_start, m.period_end,
+	       m.category_results, m.predicted_categories, m.recap_posted_at, m.created_at
+	FROM matchups m
+	JOIN teams t1 ON t1.id = m.team1_id
+	JOIN teams t2 ON t2.id = m.team2_id`
+
+func scanMatchups(rows *sql.Rows) ([]models.Matchup, error) {
+	defer rows.Close()
+
+	var matchups []models.Matchup
+	for rows.Next() {
+		var m models.Matchup
+		var team1Score, team2Score sql.NullFloat64
+		var predicted, actual sql.NullInt64
+		var start, end, posted sql.NullTime
+		var results, predictedCats []byte
+
+		err := rows.Scan(&m.ID, &m.LeagueID, &m.Week, &m.Season, &m.Team1ID, &m.Team2ID,
+			&m.Team1Name, &m.Team2Name, &team1Score, &team2Score,
+			&predicted, &actual, &start, &end,
+			&results, &predictedCats, &posted, &m.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan matchup: %w", err)
+		}
+
+		if team1Score.Valid {
+			m.Team1Score = &team1Score.Float64
+		}
+		if team2Score.Valid {
+			m.Team2Score = &team2Score.Float64
+		}
+		if predicted.Valid {
+			v := int(predicted.Int64)
+			m.PredictedWinner = &v
+		}
+		if actual.Valid {
+			v := int(actual.Int64)
+			m.ActualWinner = &v
+		}
+		if start.Valid {
+			m.PeriodStart = &start.Time
+		}
+		if end.Valid {
+			m.PeriodEnd = &end.Time
+		}
+		if posted.Valid {
+			m.RecapPostedAt = &posted.Time
+		}
+		if len(results) > 0 {
+			if err := json.Unmarshal(results, &m.CategoryResults); err != nil {
+				return nil, fmt.Errorf("failed to decode category results for matchup %d: %w", m.ID, err)
+			}
+		}
+		if len(predictedCats) > 0 {
+			if err := json.Unmarshal(predictedCats, &m.PredictedCategories); err != nil {
+				return nil, fmt.Errorf("failed to decode predicted categories for matchup %d: %w", m.ID, err)
+			}
+		}
+
+		matchups = append(matchups, m)
+	}
+	return matchups, rows.Err()
+}
+
+// GetMatchups returns every matchup in a league's scoring period
+func (db *DB) GetMatchups(ctx context.Context, leagueID string, season, week int) ([]models.Matchup, error) {
+	rows, err := db.QueryContext(ctx, matchupQuery+`
+		WHERE m.league_id = $1 AND m.season = $2 AND m.week = $3
+		ORDER BY m.id`, leagueID, season, week)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get matchups: %w", err)
+	}
+	return scanMatchups(rows)
+}
+
+// ListPendingRecaps returns completed matchups whose period ended before
+// asOf and whose recap has not been posted, oldest period first
+func (db *DB) ListPendingRecaps(ctx context.Context, leagueID string, asOf time.Time) ([]models.Matchup, error) {
+	rows, err := db.QueryContext(ctx, matchupQuery+`
+		WHERE m.league_id = $1
+		  AND m.recap_posted_at IS NULL
+		  AND m.period_end < $2::date
+		  AND m.category_results IS NOT NULL
+		ORDER BY m.season, m.week, m.id`, leagueID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending recaps: %w", err)
+	}
+	return scanMatchups(rows)
+}
+
+// MarkRecapPosted records that a period's recap was posted. Like
+// ListPendingRecaps it only covers decided matchups whose period ended
+// before asOf, so anything the recap didn't include stays pending for a
+// later one. It returns ErrNotFound when no such unposted matchups exist
+// for the period.
+func (db *DB) MarkRecapPosted(ctx context.Context, leagueID string, season, week int, asOf time.Time) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE matchups SET recap_posted_at = NOW()
+		WHERE league_id = $1 AND season = $2 AND week = $3
+		  AND recap_posted_at IS NULL
+		  AND period_end < $4::date
+		  AND category_results IS NOT NULL`,
+		leagueID, season, week, asOf)
+	if err != nil {
+		return fmt.Errorf("failed to mark recap posted: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetTeamMVP returns the rostered player with the highest total fantasy value
+// between start and end inclusive. Team rosters are stored in roster_json as
+// an array of {"espn_id": ..., "name": ...} objects.
+func (db *DB) GetTeamMVP(ctx context.Context, teamID int, start, end time.Time) (*models.TeamMVP, error) {
+	var mvp models.TeamMVP
+	err := db.QueryRowContext(ctx, `
+		SELECT p.id, p.name, COUNT(s.id), SUM(s.fantasy_value)
+		FROM teams t
+		CROSS JOIN LATERAL jsonb_array_elements(t.roster_json) AS r(entry)
+		JOIN players p ON p.espn_id = (r.entry->>'espn_id')::int
+		JOIN player_stats_daily s ON s.player_id = p.id AND s.date BETWEEN $2 AND $3
+		WHERE t.id = $1
+		GROUP BY p.id, p.name
+		ORDER BY SUM(s.fantasy_value) DESC
+		LIMIT 1`, teamID, start, end).Scan(&mvp.PlayerID, &mvp.Name, &mvp.GamesPlayed, &mvp.FantasyValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MVP for team %d: %w", teamID, err)
+	}
+	return &mvp, nil
+}
+
+// SetMatchupPrediction stores the pre-period prediction for a synced
+// matchup. It is only replaced until the period starts, so the recap
+// compares the result with what was predicted beforehand; later calls leave
+// it alone. PredictedWinner is nil for an even split.
+func (db *DB) SetMatchupPrediction(ctx context.Context, m models.Matchup) error {
+	categories, err := json.Marshal(m.PredictedCategories)
+	if err != nil {
+		return fmt.Errorf("failed to encode predicted categories: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		UPDATE matchups SET predicted_winner = $6, predicted_categories = $7
+		WHERE league_id = $1 AND season = $2 AND week = $3 AND team1_id = $4 AND team2_id = $5
+		  AND (period_start > CURRENT_DATE
+		       OR (period_start IS NULL AND predicted_categories IS NULL))`,
+		m.LeagueID, m.Season, m.Week, m.Team1ID, m.Team2ID, m.PredictedWinner, categories)
+	if err != nil {
+		return fmt.Errorf("failed to store prediction for week %d: %w", m.Week, err)
+	}
+	return nil
+}
+
+// UpsertMatchup stores a synced matchup, keyed by league, period and teams.
+// Predictions, set by SetMatchupPrediction, and recap state are left
+// untouched.
+func (db *DB) UpsertMatchup(ctx context.Context, m models.Matchup) error {
+	var results []byte
+	if m.CategoryResults != nil {
+		var err error
+		if results, err = json.Marshal(m.CategoryResults); err != nil {
+			return fmt.Errorf("failed to encode category results: %w", err)
+		}
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO matchups (league_id, week, season, team1_id, team2_id, team1_score, team2_score,
+		                      actual_winner, period_start, period_end, category_results)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (league_id, week, season, team1_id, team2_id) DO UPDATE SET
+			team1_score = EXCLUDED.team1_score,
+			team2_score = EXCLUDED.team2_score,
+			actual_winner = EXCLUDED.actual_winner,
+			period_start = EXCLUDED.period_start,
+			period_end = EXCLUDED.period_end,
+			category_results = EXCLUDED.category_results`,
+		m.LeagueID, m.Week, m.Season, m.Team1ID, m.Team2ID, m.Team1Score, m.Team2Score,
+		m.ActualWinner, m.PeriodStart, m.PeriodEnd, results)
+	if err != nil {
+		return fmt.Errorf("failed to upsert matchup for week %d: %w", m.Week, err)
+	}
+	return nil
+}
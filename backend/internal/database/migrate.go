@@ -0,0 +1,344 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock held while migrating, so two
+// deploys starting together don't apply the same migration twice
+const migrationLockKey = 7349211500831
+
+// ErrSchemaOutdated is returned by CheckSchema when migrations are pending
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// Migration is one numbered schema change from migrations/, written as
+// NNN_name.up.sql with a matching NNN_name.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration and when it was applied, if it has been
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return parseMigrations(files)
+}
+
+// parseMigrations reads the migrations at the root of fsys
+func parseMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, f := range files {
+		name := f.Name()
+		base, direction, ok := cutMigrationName(name)
+		if !ok {
+			return nil, fmt.Errorf("migration %s must be named NNN_name.up.sql or NNN_name.down.sql", name)
+		}
+		prefix, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s has no version number", name)
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %03d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func cutMigrationName(name string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// LatestSchemaVersion returns the version of the newest embedded migration
+func LatestSchemaVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// SchemaVersion returns the newest applied migration, or 0 when none are
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	applied, err := appliedMigrations(ctx, db.DB)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// CheckSchema returns ErrSchemaOutdated when embedded migrations have not
+// been applied. A schema newer than this build is allowed so an older build
+// can keep running during a deploy.
+func (db *DB) CheckSchema(ctx context.Context) error {
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%03d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// MigrationStatus lists every embedded migration and when it was applied
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationState{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			s.AppliedAt = &at
+		}
+		states = append(states, s)
+	}
+	return states, nil
+}
+
+// MigrateUp applies every pending migration
+func (db *DB) MigrateUp(ctx context.Context) ([]Migration, error) {
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	return db.MigrateTo(ctx, latest)
+}
+
+// MigrateDown reverts the newest applied migration
+func (db *DB) MigrateDown(ctx context.Context) ([]Migration, error) {
+	current, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, nil
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	target := 0
+	for _, m := range migrations {
+		if m.Version < current {
+			target = m.Version
+		}
+	}
+	return db.MigrateTo(ctx, target)
+}
+
+// MigrateTo applies pending migrations up to and including version, and
+// reverts applied migrations newer than it. Each migration runs in its own
+// transaction, and an advisory lock keeps concurrent runs from overlapping.
+// It returns the migrations that were applied or reverted, in order.
+func (db *DB) MigrateTo(ctx context.Context, version int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && !hasVersion(migrations, version) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	// Session advisory locks belong to one connection, so pin one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	// Read applied versions only after taking the lock, so a run that
+	// waited sees what the other one did
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok || m.Version > version {
+			continue
+		}
+		if err := runMigration(ctx, conn, m, true); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok || m.Version <= version {
+			continue
+		}
+		if err := runMigration(ctx, conn, m, false); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+func runMigration(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %03d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	script, record := m.Down, `DELETE FROM schema_migrations WHERE version = $1`
+	args := []interface{}{m.Version}
+	if up {
+		script, record = m.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+		args = append(args, m.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %03d_%s failed: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %03d: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %03d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// querier is the part of *sql.DB and *sql.Conn appliedMigrations uses
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// appliedMigrations returns when each applied migration ran. A database
+// that has never been migrated has none.
+func appliedMigrations(ctx context.Context, q querier) (map[int]time.Time, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for schema_migrations: %w", err)
+	}
+	applied := make(map[int]time.Time)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func hasVersion(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, want versions numbered 1 to %d without gaps", i, m.Version, len(migrations))
+		}
+		if m.Name == "" {
+			t.Errorf("migration %d has no name", m.Version)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %03d_%s has an empty up or down file", m.Version, m.Name)
+		}
+	}
+
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := migrations[len(migrations)-1].Version; latest != want {
+		t.Errorf("LatestSchemaVersion = %d, want %d", latest, want)
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"010_tokens.up.sql":    file("up 10"),
+				"010_tokens.down.sql":  file("down 10"),
+				"002_recaps.up.sql":    file("up 2"),
+				"002_recaps.down.sql":  file("down 2"),
+				"001_initial.up.sql":   file("up 1"),
+				"001_initial.down.sql": file("down 1"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "initial", Up: "up 1", Down: "down 1"},
+				{Version: 2, Name: "recaps", Up: "up 2", Down: "down 2"},
+				{Version: 10, Name: "tokens", Up: "up 10", Down: "down 10"},
+			},
+		},
+		{
+			name: "name with underscores",
+			files: fstest.MapFS{
+				"003_response_cache.up.sql":   file("up"),
+				"003_response_cache.down.sql": file("down"),
+			},
+			want: []Migration{{Version: 3, Name: "response_cache", Up: "up", Down: "down"}},
+		},
+		{
+			name:  "empty",
+			files: fstest.MapFS{},
+			want:  []Migration{},
+		},
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"001_initial.up.sql": file("up")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "missing up",
+			files:   fstest.MapFS{"001_initial.down.sql": file("down")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "not sql",
+			files:   fstest.MapFS{"README.md": file("notes")},
+			wantErr: "must be named",
+		},
+		{
+			name:    "no direction",
+			files:   fstest.MapFS{"001_initial.sql": file("up")},
+			wantErr: "must be named",
+		},
+		{
+			name:    "no version",
+			files:   fstest.MapFS{"initial.up.sql": file("up"), "initial.down.sql": file("down")},
+			wantErr: "has no version number",
+		},
+		{
+			name:    "version zero",
+			files:   fstest.MapFS{"000_initial.up.sql": file("up"), "000_initial.down.sql": file("down")},
+			wantErr: "has no version number",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"001_initial.up.sql":   file("up"),
+				"001_initial.down.sql": file("down"),
+				"001_other.up.sql":     file("up"),
+				"001_other.down.sql":   file("down"),
+			},
+			wantErr: "migration version 1 is used by both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("migration %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHasVersion(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 5}}
+
+	tests := []struct {
+		version int
+		want    bool
+	}{
+		{1, true},
+		{5, true},
+		{3, false},
+		{0, false},
+	}
+	for _, tt := range tests {
+		if got := hasVersion(migrations, tt.version); got != tt.want {
+			t.Errorf("hasVersion(%d) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
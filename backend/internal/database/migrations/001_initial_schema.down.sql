@@ -0,0 +1,12 @@
+-- Drops the core tables and everything that references them
+
+DROP TABLE IF EXISTS nba_team_schedules;
+DROP TABLE IF EXISTS matchups;
+DROP TABLE IF EXISTS weekly_streaming_results;
+DROP TABLE IF EXISTS player_stats_daily;
+DROP TABLE IF EXISTS players;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS leagues;
+DROP TABLE IF EXISTS users;
+
+DROP FUNCTION IF EXISTS update_updated_at_column();
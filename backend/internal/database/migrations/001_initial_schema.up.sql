@@ -113,13 +113,13 @@ CREATE TABLE IF NOT EXISTS nba_team_schedules (
 );
 
 -- Indexes for performance
-CREATE INDEX idx_player_stats_player_date ON player_stats_daily(player_id, date DESC);
-CREATE INDEX idx_player_stats_date ON player_stats_daily(date DESC);
-CREATE INDEX idx_teams_league ON teams(league_id);
-CREATE INDEX idx_matchups_league_week ON matchups(league_id, week, season);
-CREATE INDEX idx_streaming_results_week ON weekly_streaming_results(week, season);
-CREATE INDEX idx_players_active ON players(active) WHERE active = true;
-CREATE INDEX idx_nba_schedules_week ON nba_team_schedules(week, season);
+CREATE INDEX IF NOT EXISTS idx_player_stats_player_date ON player_stats_daily(player_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_player_stats_date ON player_stats_daily(date DESC);
+CREATE INDEX IF NOT EXISTS idx_teams_league ON teams(league_id);
+CREATE INDEX IF NOT EXISTS idx_matchups_league_week ON matchups(league_id, week, season);
+CREATE INDEX IF NOT EXISTS idx_streaming_results_week ON weekly_streaming_results(week, season);
+CREATE INDEX IF NOT EXISTS idx_players_active ON players(active) WHERE active = true;
+CREATE INDEX IF NOT EXISTS idx_nba_schedules_week ON nba_team_schedules(week, season);
 
 -- Updated_at trigger function
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -130,18 +130,24 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
--- Add updated_at triggers
+-- Add updated_at triggers. Dropped first because CREATE TRIGGER has no
+-- IF NOT EXISTS, so the migration can run against an existing schema.
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
 CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_leagues_updated_at ON leagues;
 CREATE TRIGGER update_leagues_updated_at BEFORE UPDATE ON leagues
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_teams_updated_at ON teams;
 CREATE TRIGGER update_teams_updated_at BEFORE UPDATE ON teams
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_players_updated_at ON players;
 CREATE TRIGGER update_players_updated_at BEFORE UPDATE ON players
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_nba_schedules_updated_at ON nba_team_schedules;
 CREATE TRIGGER update_nba_schedules_updated_at BEFORE UPDATE ON nba_team_schedules
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
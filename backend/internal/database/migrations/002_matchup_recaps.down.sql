@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_matchups_pending_recap;
+
+ALTER TABLE matchups DROP COLUMN IF EXISTS recap_posted_at;
+ALTER TABLE matchups DROP COLUMN IF EXISTS predicted_categories;
+ALTER TABLE matchups DROP COLUMN IF EXISTS category_results;
+ALTER TABLE matchups DROP COLUMN IF EXISTS period_end;
+ALTER TABLE matchups DROP COLUMN IF EXISTS period_start;
@@ -0,0 +1,14 @@
+-- Matchup recaps
+-- Store per-category outcomes and predictions alongside each matchup so the
+-- weekly recap can compare them, and track which weeks have been posted.
+
+-- category_results and predicted_categories hold JSON arrays of
+-- {"category": "PTS", "team1": 512, "team2": 480}
+ALTER TABLE matchups ADD COLUMN IF NOT EXISTS period_start DATE;
+ALTER TABLE matchups ADD COLUMN IF NOT EXISTS period_end DATE;
+ALTER TABLE matchups ADD COLUMN IF NOT EXISTS category_results JSONB;
+ALTER TABLE matchups ADD COLUMN IF NOT EXISTS predicted_categories JSONB;
+ALTER TABLE matchups ADD COLUMN IF NOT EXISTS recap_posted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_matchups_pending_recap ON matchups(league_id, period_end)
+    WHERE recap_posted_at IS NULL;
@@ -0,0 +1,16 @@
+-- Response cache
+-- Shared store for cached ESPN responses when CACHE_BACKEND=postgres, so
+-- entries survive restarts and are shared between API instances.
+
+CREATE TABLE IF NOT EXISTS response_cache (
+    key TEXT PRIMARY KEY,
+    status INTEGER NOT NULL,
+    header JSONB NOT NULL DEFAULT '{}',
+    body BYTEA NOT NULL,
+    etag TEXT NOT NULL,
+    stored_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    fresh_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_response_cache_expires ON response_cache(expires_at);
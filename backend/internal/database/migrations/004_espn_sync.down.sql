@@ -0,0 +1,12 @@
+-- Fails if the sync has since stored two teams with the same name in a
+-- league; rename or remove one of them first.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'teams_league_id_team_name_key') THEN
+        ALTER TABLE teams ADD CONSTRAINT teams_league_id_team_name_key UNIQUE (league_id, team_name);
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_teams_league_espn_team;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS espn_team_id;
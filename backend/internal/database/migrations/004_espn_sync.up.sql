@@ -0,0 +1,11 @@
+-- ESPN sync
+-- Link teams to their ESPN team ID so the league sync can upsert teams and
+-- their matchups. Teams are identified by that ID from now on, so the
+-- unique team name goes: ESPN allows two teams the same display name, and
+-- teams swapping names mid-season would clash with each other.
+
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS espn_team_id INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_league_espn_team ON teams(league_id, espn_team_id);
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_league_id_team_name_key;
@@ -0,0 +1,18 @@
+-- League transactions
+-- Adds, drops, waiver claims and trades ingested from the ESPN activity
+-- feed. moves holds a JSON array of
+-- {"action": "ADD", "player_espn_id": 3945274, "player_name": "...",
+--  "team_espn_id": 4, "team_name": "...", "waiver": true}
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id SERIAL PRIMARY KEY,
+    league_id VARCHAR(50) NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    espn_id VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    moves JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(league_id, espn_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_league_occurred ON transactions(league_id, occurred_at DESC);
@@ -0,0 +1,8 @@
+-- Transaction announcements
+-- Track which transactions the Discord bot has posted so announcements
+-- survive bot restarts without repeating.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS announced_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_unannounced ON transactions(league_id, occurred_at)
+    WHERE announced_at IS NULL;
@@ -0,0 +1,12 @@
+-- League members
+-- Link users to the ESPN leagues they belong to. The API reads a league
+-- with the ESPN cookies of one of its members.
+
+CREATE TABLE IF NOT EXISTS league_members (
+    league_id VARCHAR(50) NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (league_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_league_members_user ON league_members(user_id);
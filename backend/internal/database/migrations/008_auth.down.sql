@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_tokens;
+
+DROP INDEX IF EXISTS idx_users_auth_id;
+ALTER TABLE users DROP COLUMN IF EXISTS auth_id;
@@ -0,0 +1,18 @@
+-- API authentication
+-- Links users to their Supabase auth account and stores hashed API tokens
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_auth_id ON users(auth_id);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    -- SHA-256 of the token; the token itself is never stored
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);
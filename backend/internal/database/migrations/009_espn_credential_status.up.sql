@@ -0,0 +1,6 @@
+-- ESPN credential status
+-- Tracks whether a user's ESPN cookies still work so expired ones stop being
+-- used and the user can be told to refresh them
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS espn_status VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS espn_checked_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// ErrNotFound is returned when a lookup matches no rows
+var ErrNotFound = errors.New("not found")
+
+const playerColumns = `id, espn_id, name, position, team, active, created_at, updated_at`
+
+func scanPlayer(row interface{ Scan(...interface{}) error }) (models.Player, error) {
+	var p models.Player
+	var espnID sql.NullInt64
+	err := row.Scan(&p.ID, &espnID, &p.Name, &p.Position, &p.Team, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	if espnID.Valid {
+		id := int(espnID.Int64)
+		p.ESPNID = &id
+	}
+	return p, err
+}
+
+// ListActivePlayers returns every active player, ordered by name
+func (db *DB) ListActivePlayers(ctx context.Context) ([]models.Player, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+playerColumns+` FROM players WHERE active = true ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list players: %w", err)
+	}
+	defer rows.Close()
+
+	var players []models.Player
+	for rows.Next() {
+		p, err := scanPlayer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player: %w", err)
+		}
+		players = append(players, p)
+	}
+	return players, rows.Err()
+}
+
+// GetPlayer returns a single player by ID
+func (db *DB) GetPlayer(ctx context.Context, id int) (*models.Player, error) {
+	row := db.QueryRowContext(ctx, `SELECT `+playerColumns+` FROM players WHERE id = $1`, id)
+	p, err := scanPlayer(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player %d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// GetRecentPlayerStats returns the player's last n game lines in
+// chronological order
+func (db *DB) GetRecentPlayerStats(ctx context.Context, playerID, n int) ([]models.PlayerStats, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, player_id, date, points, rebounds, assists, steals, blocks, turnovers,
+		       threes_made, fgm, fga, ftm, fta, minutes, fantasy_value, created_at
+		FROM (
+			SELECT * FROM player_stats_daily
+			WHERE player_id = $1
+			ORDER BY date DESC
+			LIMIT $2
+		) recent
+		ORDER BY date ASC`, playerID, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats for player %d: %w", playerID, err)
+	}
+	defer rows.Close()
+
+	var stats []models.PlayerStats
+	for rows.Next() {
+		var s models.PlayerStats
+		if err := rows.Scan(&s.ID, &s.PlayerID, &s.Date, &s.Points, &s.Rebounds, &s.Assists,
+			&s.Steals, &s.Blocks, &s.Turnovers, &s.ThreesMade, &s.FGM, &s.FGA, &s.FTM, &s.FTA,
+			&s.Minutes, &s.FantasyValue, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan player stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetPlayerAverages returns per-game averages for games played on or after since
+func (db *DB) GetPlayerAverages(ctx context.Context, playerID int, since time.Time) (*models.PlayerAverages, error) {
+	var a models.PlayerAverages
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(AVG(points), 0), COALESCE(AVG(rebounds), 0), COALESCE(AVG(assists), 0),
+		       COALESCE(AVG(steals), 0), COALESCE(AVG(blocks), 0), COALESCE(AVG(turnovers), 0),
+		       COALESCE(AVG(threes_made), 0),
+		       COALESCE(SUM(fgm) / NULLIF(SUM(fga), 0), 0),
+		       COALESCE(SUM(ftm) / NULLIF(SUM(fta), 0), 0),
+		       COALESCE(AVG(minutes), 0), COALESCE(AVG(fantasy_value), 0)
+		FROM player_stats_daily
+		WHERE player_id = $1 AND date >= $2`, playerID, since).Scan(
+		&a.GamesPlayed, &a.Points, &a.Rebounds, &a.Assists, &a.Steals, &a.Blocks, &a.Turnovers,
+		&a.ThreesMade, &a.FGPct, &a.FTPct, &a.Minutes, &a.FantasyValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get averages for player %d: %w", playerID, err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/milindkumar1/swishradar/internal/models"
+)
+
+// InsertTransactions stores transactions not seen before and returns how
+// many were new. The batch is stored all or nothing.
+func (db *DB) InsertTransactions(ctx context.Context, transactions []models.Transaction) (int, error) {
+	inserted := 0
+	err := db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		inserted = 0
+		for _, t := range transactions {
+			moves, err := json.Marshal(t.Moves)
+			if err != nil {
+				return fmt.Errorf("failed to encode moves: %w", err)
+			}
+
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO transactions (league_id, espn_id, type, occurred_at, moves)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (league_id, espn_id) DO NOTHING`,
+				t.LeagueID, t.ESPNID, t.Type, t.OccurredAt, moves)
+			if err != nil {
+				return fmt.Errorf("failed to insert transaction %s: %w", t.ESPNID, err)
+			}
+			if n, err := res.RowsAffected(); err == nil {
+				inserted += int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+const transactionColumns = `id, league_id, espn_id, type, occurred_at, moves, announced_at, created_at`
+
+func scanTransactions(rows *sql.Rows) ([]models.Transaction, error) {
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		var moves []byte
+		var announced sql.NullTime
+		if err := rows.Scan(&t.ID, &t.LeagueID, &t.ESPNID, &t.Type, &t.OccurredAt, &moves, &announced, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := json.Unmarshal(moves, &t.Moves); err != nil {
+			return nil, fmt.Errorf("failed to decode moves for transaction %d: %w", t.ID, err)
+		}
+		if announced.Valid {
+			t.AnnouncedAt = &announced.Time
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// ListTransactions returns a league's transactions newest first, optionally
+// only those after since
+func (db *DB) ListTransactions(ctx context.Context, leagueID string, since time.Time, limit int) ([]models.Transaction, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE league_id = $1 AND occurred_at > $2
+		ORDER BY occurred_at DESC
+		LIMIT $3`, leagueID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	return scanTransactions(rows)
+}
+
+// ListUnannouncedTransactions returns transactions after since that have not
+// been announced, oldest first
+func (db *DB) ListUnannouncedTransactions(ctx context.Context, leagueID string, since time.Time, limit int) ([]models.Transaction, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE league_id = $1 AND occurred_at > $2 AND announced_at IS NULL
+		ORDER BY occurred_at, id
+		LIMIT $3`, leagueID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unannounced transactions: %w", err)
+	}
+	return scanTransactions(rows)
+}
+
+// MarkTransactionAnnounced records that a transaction was posted. It returns
+// ErrNotFound when the transaction does not exist or was already announced.
+func (db *DB) MarkTransactionAnnounced(ctx context.Context, leagueID string, id int) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE transactions SET announced_at = NOW()
+		WHERE league_id = $1 AND id = $2 AND announced_at IS NULL`, leagueID, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark transaction announced: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetRecentFantasyValues returns the average fantasy value per game since
+// the given date for each ESPN player ID that has games in that window
+func (db *DB) GetRecentFantasyValues(ctx context.Context, espnIDs []int, since time.Time) (map[int]float64, error) {
+	values := make(map[int]float64, len(espnIDs))
+	if len(espnIDs) == 0 {
+		return values, nil
+	}
+
+	ids := make([]int64, len(espnIDs))
+	for i, id := range espnIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.espn_id, AVG(s.fantasy_value)
+		FROM players p
+		JOIN player_stats_daily s ON s.player_id = p.id AND s.date >= $2
+		WHERE p.espn_id = ANY($1)
+		GROUP BY p.espn_id`, pq.Array(ids), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent fantasy values: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var value float64
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan fantasy value: %w", err)
+		}
+		values[id] = value
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// txAttempts is how many times WithTx runs a transaction that keeps hitting
+// serialization failures or deadlocks
+const txAttempts = 3
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise. Serialization failures and deadlocks are retried with a
+// short backoff, so fn must be safe to run more than once and should only
+// touch the database through tx.
+func (db *DB) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		err = db.runTx(ctx, opts, fn)
+		if err == nil || !retryableTxError(err) || attempt == txAttempts {
+			break
+		}
+
+		backoff := time.Duration(attempt*attempt)*10*time.Millisecond +
+			time.Duration(rand.Int63n(int64(10*time.Millisecond)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	return err
+}
+
+func (db *DB) runTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// retryableTxError reports whether err is a serialization failure (40001)
+// or deadlock (40P01), which succeed when the transaction is run again
+func retryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/milindkumar1/swishradar/internal/models"
+	"github.com/milindkumar1/swishradar/internal/secrets"
+)
+
+// ErrNoCredentialKey is returned when ESPN cookies are stored without a
+// credential key configured
+var ErrNoCredentialKey = errors.New("credential encryption key is not configured")
+
+// Column names double as the additional authenticated data for encrypted
+// cookies, so a value copied into the other column fails to decrypt
+const (
+	swidColumn = "users.espn_swid"
+	s2Column   = "users.espn_s2"
+)
+
+// ESPN credential statuses stored in users.espn_status
+const (
+	ESPNStatusValid   = "valid"
+	ESPNStatusExpired = "expired"
+)
+
+const userColumns = `id, email, display_name, espn_swid, espn_s2, espn_status, espn_checked_at, created_at, updated_at`
+
+func (db *DB) scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var u models.User
+	var swid, s2, status sql.NullString
+	var checkedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Email, &u.DisplayName, &swid, &s2, &status, &checkedAt, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if status.Valid {
+		u.ESPNStatus = &status.String
+	}
+	if checkedAt.Valid {
+		u.ESPNCheckedAt = &checkedAt.Time
+	}
+	var err error
+	if u.ESPNSWID, err = db.openCredential(swidColumn, swid); err != nil {
+		return nil, fmt.Errorf("user %d: %w", u.ID, err)
+	}
+	if u.ESPNS2, err = db.openCredential(s2Column, s2); err != nil {
+		return nil, fmt.Errorf("user %d: %w", u.ID, err)
+	}
+	return &u, nil
+}
+
+// GetUser returns a user with their ESPN cookies decrypted, or ErrNotFound
+func (db *DB) GetUser(ctx context.Context, id int) (*models.User, error) {
+	u, err := db.scanUser(db.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", id, err)
+	}
+	return u, nil
+}
+
+// SetUserESPNCredentials encrypts and stores a user's ESPN cookies, which
+// the caller has checked against ESPN. It returns ErrNotFound if the user
+// does not exist.
+func (db *DB) SetUserESPNCredentials(ctx context.Context, userID int, swid, s2 string) error {
+	if db.keys == nil {
+		return ErrNoCredentialKey
+	}
+	sealedSWID, err := db.keys.Encrypt(swid, swidColumn)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt SWID: %w", err)
+	}
+	sealedS2, err := db.keys.Encrypt(s2, s2Column)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt espn_s2: %w", err)
+	}
+
+	res, err := db.ExecContext(ctx, `
+		UPDATE users
+		SET espn_swid = $2, espn_s2 = $3, espn_status = '`+ESPNStatusValid+`',
+		    espn_checked_at = NOW(), updated_at = NOW()
+		WHERE id = $1`, userID, sealedSWID, sealedS2)
+	if err != nil {
+		return fmt.Errorf("failed to store credentials for user %d: %w", userID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClearUserESPNCredentials removes a user's ESPN cookies. It returns
+// ErrNotFound if the user does not exist.
+func (db *DB) ClearUserESPNCredentials(ctx context.Context, userID int) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE users
+		SET espn_swid = NULL, espn_s2 = NULL, espn_status = NULL,
+		    espn_checked_at = NULL, updated_at = NOW()
+		WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear credentials for user %d: %w", userID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkESPNCredentialsExpired records that ESPN rejected a user's cookies so
+// they stop being used. It reports whether the status changed, so callers
+// can notify the user once.
+func (db *DB) MarkESPNCredentialsExpired(ctx context.Context, userID int) (bool, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE users SET espn_status = '`+ESPNStatusExpired+`', espn_checked_at = NOW()
+		WHERE id = $1 AND espn_swid IS NOT NULL
+		  AND espn_status IS DISTINCT FROM '`+ESPNStatusExpired+`'`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark credentials expired for user %d: %w", userID, err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReencryptCredentials seals every stored ESPN cookie that is plaintext or
+// uses an old key with the primary key, returning the number of users
+// updated. Run it after adding a new primary key; once it reports zero the
+// old key can be removed.
+func (db *DB) ReencryptCredentials(ctx context.Context) (int, error) {
+	if db.keys == nil {
+		return 0, ErrNoCredentialKey
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, espn_swid, espn_s2 FROM users
+		WHERE espn_swid IS NOT NULL OR espn_s2 IS NOT NULL
+		ORDER BY id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	type stored struct {
+		id       int
+		swid, s2 sql.NullString
+	}
+	var pending []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.id, &s.swid, &s.s2); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan credentials: %w", err)
+		}
+		if db.needsRotation(s.swid) || db.needsRotation(s.s2) {
+			pending = append(pending, s)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	updated := 0
+	for _, s := range pending {
+		swid, err := db.resealCredential(swidColumn, s.swid)
+		if err != nil {
+			return updated, fmt.Errorf("user %d: %w", s.id, err)
+		}
+		s2, err := db.resealCredential(s2Column, s.s2)
+		if err != nil {
+			return updated, fmt.Errorf("user %d: %w", s.id, err)
+		}
+
+		// Only update rows that still hold the values read above, so cookies
+		// saved in the meantime are not overwritten
+		res, err := db.ExecContext(ctx, `
+			UPDATE users SET espn_swid = $2, espn_s2 = $3
+			WHERE id = $1
+			  AND espn_swid IS NOT DISTINCT FROM $4
+			  AND espn_s2 IS NOT DISTINCT FROM $5`,
+			s.id, swid, s2, s.swid, s.s2)
+		if err != nil {
+			return updated, fmt.Errorf("failed to update credentials for user %d: %w", s.id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// openCredential decrypts a stored cookie. Plaintext values from before
+// encryption was enabled are returned as they are.
+func (db *DB) openCredential(column string, v sql.NullString) (*string, error) {
+	if !v.Valid {
+		return nil, nil
+	}
+	if db.keys == nil {
+		if secrets.IsEncrypted(v.String) {
+			return nil, ErrNoCredentialKey
+		}
+		return &v.String, nil
+	}
+	plain, err := db.keys.Decrypt(v.String, column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	return &plain, nil
+}
+
+func (db *DB) needsRotation(v sql.NullString) bool {
+	return v.Valid && db.keys.NeedsRotation(v.String)
+}
+
+func (db *DB) resealCredential(column string, v sql.NullString) (sql.NullString, error) {
+	if !v.Valid {
+		return v, nil
+	}
+	plain, err := db.keys.Decrypt(v.String, column)
+	if err != nil {
+		return v, fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	sealed, err := db.keys.Encrypt(plain, column)
+	if err != nil {
+		return v, fmt.Errorf("failed to encrypt %s: %w", column, err)
+	}
+	return sql.NullString{String: sealed, Valid: true}, nil
+}
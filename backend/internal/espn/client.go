@@ -1,13 +1,50 @@
 package espn
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+	"github.com/milindkumar1/swishradar/internal/ratelimit"
 )
 
+// ErrUnauthorized is returned when ESPN rejects the client's cookies, which
+// usually means espn_s2 has expired or the account cannot see the league
+var ErrUnauthorized = errors.New("ESPN rejected the league credentials")
+
+// unauthorized reports whether an ESPN status means the cookies were
+// rejected. Trying other seasons won't help in that case.
+func unauthorized(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// Host is where ESPN Fantasy API requests go, for ratelimit.SetHostLimit
+const Host = "fantasy.espn.com"
+
+// transport is shared by every Client, so identical requests from
+// different handlers share one call and all of them count against the
+// host's rate limit
+var transport = &ratelimit.Transport{
+	Next: &metrics.Transport{Upstream: "espn", Next: &logging.Transport{Upstream: "espn"}},
+}
+
+// CurrentSeason returns ESPN's ID for the NBA season under way at now.
+// Seasons are named for the year they end in and start in October, so
+// October 2026 is in season 2027.
+func CurrentSeason(now time.Time) int {
+	if now.Month() >= time.October {
+		return now.Year() + 1
+	}
+	return now.Year()
+}
+
 // Client handles ESPN Fantasy API requests
 type Client struct {
 	LeagueID string
@@ -25,45 +62,71 @@ func NewClient(leagueID string, season int, swid, s2 string) *Client {
 		SWID:     swid,
 		S2:       s2,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
 
 // League represents the ESPN league data
 type League struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Size     int    `json:"size"`
-	Season   int    `json:"seasonId"`
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	Season int    `json:"seasonId"`
+	Status struct {
+		CurrentMatchupPeriod int `json:"currentMatchupPeriod"`
+		LatestScoringPeriod  int `json:"latestScoringPeriod"`
+	} `json:"status"`
 	Settings struct {
 		Name            string          `json:"name"`
 		ScoringSettings json.RawMessage `json:"scoringSettings"`
 		RosterSettings  json.RawMessage `json:"rosterSettings"`
+		// ScheduleSettings.MatchupPeriods maps each matchup period ID to
+		// the scoring period (day) IDs it covers
+		ScheduleSettings struct {
+			MatchupPeriods map[string][]int `json:"matchupPeriods"`
+		} `json:"scheduleSettings"`
 	} `json:"settings"`
-	Teams   []Team   `json:"teams"`
-	Members []Member `json:"members"`
+	Teams    []Team    `json:"teams"`
+	Members  []Member  `json:"members"`
+	Schedule []Matchup `json:"schedule"`
 }
 
 type Team struct {
-	ID           int    `json:"id"`
-	Abbrev       string `json:"abbrev"`
-	Name         string `json:"name"`
-	Location     string `json:"location"`
-	Nickname     string `json:"nickname"`
-	PrimaryOwner string `json:"primaryOwner"`
+	ID           int      `json:"id"`
+	Abbrev       string   `json:"abbrev"`
+	Name         string   `json:"name"`
+	Location     string   `json:"location"`
+	Nickname     string   `json:"nickname"`
+	PrimaryOwner string   `json:"primaryOwner"`
+	Owners       []string `json:"owners"` // member IDs
 	Roster       struct {
 		Entries []RosterEntry `json:"entries"`
 	} `json:"roster"`
 	Record struct {
 		Overall struct {
-			Wins   int `json:"wins"`
-			Losses int `json:"losses"`
-			Ties   int `json:"ties"`
+			Wins          int     `json:"wins"`
+			Losses        int     `json:"losses"`
+			Ties          int     `json:"ties"`
+			PointsFor     float64 `json:"pointsFor"`
+			PointsAgainst float64 `json:"pointsAgainst"`
 		} `json:"overall"`
 	} `json:"record"`
 }
 
+// DisplayName returns the team's name, falling back to location/nickname or
+// abbreviation for older league data
+func (t Team) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	if name := strings.TrimSpace(t.Location + " " + t.Nickname); name != "" {
+		return name
+	}
+	return t.Abbrev
+}
+
 type RosterEntry struct {
 	PlayerPoolEntry struct {
 		Player Player `json:"player"`
@@ -72,39 +135,158 @@ type RosterEntry struct {
 }
 
 type Player struct {
-	ID                int    `json:"id"`
-	FullName          string `json:"fullName"`
-	FirstName         string `json:"firstName"`
-	LastName          string `json:"lastName"`
-	DefaultPositionId int    `json:"defaultPositionId"`
-	ProTeamId         int    `json:"proTeamId"`
-	Injured           bool   `json:"injured"`
-	InjuryStatus      string `json:"injuryStatus"`
+	ID                int         `json:"id"`
+	FullName          string      `json:"fullName"`
+	FirstName         string      `json:"firstName"`
+	LastName          string      `json:"lastName"`
+	DefaultPositionId int         `json:"defaultPositionId"`
+	ProTeamId         int         `json:"proTeamId"`
+	Injured           bool        `json:"injured"`
+	InjuryStatus      string      `json:"injuryStatus"`
+	Stats             []StatSplit `json:"stats"`
+	Ownership         *Ownership  `json:"ownership"`
+}
+
+// Ownership holds ESPN-wide roster percentages for a player
+type Ownership struct {
+	PercentOwned   float64 `json:"percentOwned"`
+	PercentStarted float64 `json:"percentStarted"`
+	PercentChange  float64 `json:"percentChange"`
+}
+
+// PoolPlayer is a player's entry in a league's player pool
+type PoolPlayer struct {
+	ID       int                     `json:"id"`
+	OnTeamID int                     `json:"onTeamId"` // 0 when not rostered
+	Status   string                  `json:"status"`   // ONTEAM, FREEAGENT or WAIVERS
+	Player   Player                  `json:"player"`
+	Ratings  map[string]PlayerRating `json:"ratings"` // keyed by stat split type, "0" = season
+}
+
+// PlayerRating is ESPN's fantasy ranking of a player over a stat split
+type PlayerRating struct {
+	PositionalRanking int     `json:"positionalRanking"`
+	TotalRanking      int     `json:"totalRanking"`
+	TotalRating       float64 `json:"totalRating"`
+}
+
+// StatSplit is one stat line ESPN attaches to a player (season totals,
+// last 7/15/30 days, projections)
+type StatSplit struct {
+	ID              string             `json:"id"`
+	SeasonID        int                `json:"seasonId"`
+	StatSourceID    int                `json:"statSourceId"`    // 0 = actual, 1 = projected
+	StatSplitTypeID int                `json:"statSplitTypeId"` // 0 = season, 1 = last 7, 2 = last 15, 3 = last 30
+	Stats           map[string]float64 `json:"stats"`
+	AverageStats    map[string]float64 `json:"averageStats"`
+	AppliedTotal    float64            `json:"appliedTotal"`
+	AppliedAverage  float64            `json:"appliedAverage"`
+}
+
+// ESPN stat IDs used as keys in StatSplit.Stats and StatSplit.AverageStats
+const (
+	StatPoints      = "0"
+	StatBlocks      = "1"
+	StatSteals      = "2"
+	StatAssists     = "3"
+	StatRebounds    = "6"
+	StatTurnovers   = "11"
+	StatFGM         = "13"
+	StatFGA         = "14"
+	StatFTM         = "15"
+	StatFTA         = "16"
+	StatThreesMade  = "17"
+	StatFGPct       = "19"
+	StatFTPct       = "20"
+	StatMinutes     = "40"
+	StatGamesPlayed = "42"
+)
+
+// Stat split types
+const (
+	SplitSeason = 0
+	SplitLast7  = 1
+	SplitLast15 = 2
+	SplitLast30 = 3
+)
+
+// Split returns the player's actual stat line for the given split type,
+// preferring the most recent season ESPN returned
+func (p Player) Split(splitType int) (*StatSplit, bool) {
+	var best *StatSplit
+	for i := range p.Stats {
+		s := &p.Stats[i]
+		if s.StatSourceID != 0 || s.StatSplitTypeID != splitType {
+			continue
+		}
+		if best == nil || s.SeasonID > best.SeasonID {
+			best = s
+		}
+	}
+	return best, best != nil
+}
+
+// Averages returns the player's actual per-game averages for the given split
+func (p Player) Averages(splitType int) (map[string]float64, bool) {
+	s, ok := p.Split(splitType)
+	if !ok || len(s.AverageStats) == 0 {
+		return nil, false
+	}
+	return s.AverageStats, true
 }
 
 type Member struct {
 	ID              string `json:"id"`
 	DisplayName     string `json:"displayName"`
+	FirstName       string `json:"firstName"`
+	LastName        string `json:"lastName"`
 	IsLeagueManager bool   `json:"isLeagueManager"`
 }
 
+// TeamOwners returns the league members that own team, in ESPN's order
+func (l *League) TeamOwners(team Team) []Member {
+	owners := make([]Member, 0, len(team.Owners))
+	for _, id := range team.Owners {
+		for _, m := range l.Members {
+			if m.ID == id {
+				owners = append(owners, m)
+				break
+			}
+		}
+	}
+	return owners
+}
+
 // GetLeague fetches league information from ESPN
-func (c *Client) GetLeague() (*League, error) {
-	// Try current season first, then fall back to previous season
-	seasons := []int{2025, 2024, 2026}
+func (c *Client) GetLeague(ctx context.Context) (*League, error) {
+	return c.getLeague(ctx, "view=mTeam&view=mRoster&view=mSettings&view=mMatchup", "")
+}
+
+// seasons lists the seasons to request: the client's, then the one before
+// for leagues that haven't been renewed for it yet
+func (c *Client) seasons() []int {
+	return []int{c.Season, c.Season - 1}
+}
 
+// getLeague fetches the league with the given views and optional
+// X-Fantasy-Filter header
+func (c *Client) getLeague(ctx context.Context, views, filter string) (*League, error) {
 	var lastErr error
-	for _, season := range seasons {
+	for _, season := range c.seasons() {
 		url := fmt.Sprintf(
-			"https://fantasy.espn.com/apis/v3/games/fba/seasons/%d/segments/0/leagues/%s?view=mTeam&view=mRoster&view=mSettings&view=mMatchup",
+			"https://fantasy.espn.com/apis/v3/games/fba/seasons/%d/segments/0/leagues/%s?%s",
 			season,
 			c.LeagueID,
+			views,
 		)
 
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
+		if filter != "" {
+			req.Header.Set("X-Fantasy-Filter", filter)
+		}
 
 		// Add ESPN authentication cookies - try both cookie names
 		// ESPN sometimes uses different cookie names
@@ -135,6 +317,9 @@ func (c *Client) GetLeague() (*League, error) {
 		}
 		defer resp.Body.Close()
 
+		if unauthorized(resp.StatusCode) {
+			return nil, fmt.Errorf("%w (status %d)", ErrUnauthorized, resp.StatusCode)
+		}
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			bodyStr := string(body)
@@ -177,71 +362,5 @@ func (c *Client) GetLeague() (*League, error) {
 		return &league, nil
 	}
 
-	return nil, fmt.Errorf("failed to fetch league from all seasons: %v", lastErr)
-}
-
-// GetFreeAgents fetches available free agents
-func (c *Client) GetFreeAgents(limit int) ([]Player, error) {
-	// Try current season first, then fall back
-	seasons := []int{2025, 2024, 2026}
-
-	var lastErr error
-	for _, season := range seasons {
-		url := fmt.Sprintf(
-			"https://fantasy.espn.com/apis/v3/games/fba/seasons/%d/segments/0/leagues/%s?view=kona_player_info",
-			season,
-			c.LeagueID,
-		)
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.AddCookie(&http.Cookie{Name: "SWID", Value: c.SWID})
-		req.AddCookie(&http.Cookie{Name: "espn_s2", Value: c.S2})
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := c.client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to fetch free agents: %w", err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("ESPN API returned status %d for season %d", resp.StatusCode, season)
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response: %w", err)
-			continue
-		}
-
-		var data struct {
-			Players []struct {
-				Player Player `json:"player"`
-			} `json:"players"`
-		}
-
-		if err := json.Unmarshal(body, &data); err != nil {
-			lastErr = fmt.Errorf("failed to decode free agents (season %d): %w", season, err)
-			continue
-		}
-
-		players := make([]Player, 0, len(data.Players))
-		for _, p := range data.Players {
-			players = append(players, p.Player)
-			if len(players) >= limit {
-				break
-			}
-		}
-
-		return players, nil
-	}
-
-	return nil, fmt.Errorf("failed to fetch free agents from all seasons: %v", lastErr)
+	return nil, fmt.Errorf("failed to fetch league from all seasons: %w", lastErr)
 }
@@ -0,0 +1,90 @@
+package espn
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Matchup winners as reported by ESPN
+const (
+	WinnerHome      = "HOME"
+	WinnerAway      = "AWAY"
+	WinnerTie       = "TIE"
+	WinnerUndecided = "UNDECIDED"
+)
+
+// Matchup is one head-to-head pairing in the league schedule
+type Matchup struct {
+	ID              int          `json:"id"`
+	MatchupPeriodID int          `json:"matchupPeriodId"`
+	Home            MatchupTeam  `json:"home"`
+	Away            *MatchupTeam `json:"away"` // nil for a bye
+	Winner          string       `json:"winner"`
+	PlayoffTierType string       `json:"playoffTierType"` // NONE during the regular season
+}
+
+// MatchupTeam is one side of a matchup. TotalPoints is the number of
+// categories won in category leagues.
+type MatchupTeam struct {
+	TeamID          int          `json:"teamId"`
+	TotalPoints     float64      `json:"totalPoints"`
+	CumulativeScore MatchupScore `json:"cumulativeScore"`
+}
+
+// MatchupScore is a team's running category record for the period
+type MatchupScore struct {
+	Wins        int                  `json:"wins"`
+	Losses      int                  `json:"losses"`
+	Ties        int                  `json:"ties"`
+	ScoreByStat map[string]StatScore `json:"scoreByStat"` // keyed by ESPN stat ID
+}
+
+// StatScore is a team's value in one category and whether it is winning it
+type StatScore struct {
+	Score  float64 `json:"score"`
+	Result string  `json:"result"` // WIN, LOSS, TIE or empty while undecided
+}
+
+// Decided reports whether ESPN has settled the matchup
+func (m Matchup) Decided() bool {
+	return m.Winner != "" && m.Winner != WinnerUndecided
+}
+
+// WinnerTeamID returns the winning team's ID, or 0 for ties, byes and
+// undecided matchups
+func (m Matchup) WinnerTeamID() int {
+	switch {
+	case m.Winner == WinnerHome:
+		return m.Home.TeamID
+	case m.Winner == WinnerAway && m.Away != nil:
+		return m.Away.TeamID
+	}
+	return 0
+}
+
+// ScoringPeriods returns the scoring period (day) IDs covered by a matchup
+// period, according to the league's schedule settings
+func (l *League) ScoringPeriods(matchupPeriod int) []int {
+	return l.Settings.ScheduleSettings.MatchupPeriods[strconv.Itoa(matchupPeriod)]
+}
+
+// GetMatchups fetches the league schedule for one matchup period, with
+// per-category scores
+func (c *Client) GetMatchups(ctx context.Context, period int) ([]Matchup, error) {
+	filter := fmt.Sprintf(`{"schedule":{"filterMatchupPeriodIds":{"value":[%d]}}}`, period)
+
+	league, err := c.getLeague(ctx, "view=mMatchup&view=mMatchupScore", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch matchups: %w", err)
+	}
+
+	// ESPN ignores the filter for some views, so filter again here
+	matchups := make([]Matchup, 0, len(league.Schedule))
+	for _, m := range league.Schedule {
+		if m.MatchupPeriodID == period {
+			matchups = append(matchups, m)
+		}
+	}
+	return matchups, nil
+}
@@ -0,0 +1,62 @@
+package espn
+
+// positionNames maps defaultPositionId to a position abbreviation
+var positionNames = map[int]string{
+	1: "PG",
+	2: "SG",
+	3: "SF",
+	4: "PF",
+	5: "C",
+}
+
+// proTeamAbbrevs maps proTeamId to an NBA team abbreviation
+var proTeamAbbrevs = map[int]string{
+	0:  "FA",
+	1:  "ATL",
+	2:  "BOS",
+	3:  "BKN",
+	4:  "CHI",
+	5:  "CLE",
+	6:  "DAL",
+	7:  "DEN",
+	8:  "DET",
+	9:  "GSW",
+	10: "HOU",
+	11: "IND",
+	12: "LAC",
+	13: "LAL",
+	14: "MIA",
+	15: "MIL",
+	16: "MIN",
+	17: "NOP",
+	18: "NYK",
+	19: "OKC",
+	20: "ORL",
+	21: "PHL",
+	22: "PHO",
+	23: "POR",
+	24: "SAC",
+	25: "SAS",
+	26: "TOR",
+	27: "UTA",
+	28: "WAS",
+	29: "MEM",
+	30: "CHA",
+}
+
+// Position returns the player's primary position, or "N/A" if unknown
+func (p Player) Position() string {
+	if name, ok := positionNames[p.DefaultPositionId]; ok {
+		return name
+	}
+	return "N/A"
+}
+
+// ProTeam returns the abbreviation of the player's NBA team, or "N/A" if
+// unknown
+func (p Player) ProTeam() string {
+	if abbrev, ok := proTeamAbbrevs[p.ProTeamId]; ok {
+		return abbrev
+	}
+	return "N/A"
+}
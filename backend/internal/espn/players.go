@@ -0,0 +1,238 @@
+package espn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Lineup slot IDs, used to filter the player pool by position
+const (
+	SlotPG   = 0
+	SlotSG   = 1
+	SlotSF   = 2
+	SlotPF   = 3
+	SlotC    = 4
+	SlotG    = 5
+	SlotF    = 6
+	SlotUtil = 11
+)
+
+var slotsByPosition = map[string]int{
+	"PG": SlotPG,
+	"SG": SlotSG,
+	"SF": SlotSF,
+	"PF": SlotPF,
+	"C":  SlotC,
+	"G":  SlotG,
+	"F":  SlotF,
+}
+
+// SlotForPosition returns the lineup slot for a position abbreviation such
+// as "PG" or "F"
+func SlotForPosition(position string) (int, bool) {
+	slot, ok := slotsByPosition[strings.ToUpper(position)]
+	return slot, ok
+}
+
+// FreeAgentSort orders the free agent pool
+type FreeAgentSort int
+
+const (
+	// SortPercentOwned sorts by ESPN-wide roster percentage, highest first
+	SortPercentOwned FreeAgentSort = iota
+	// SortLast7Points sorts by fantasy points over the last 7 days
+	SortLast7Points
+)
+
+// FreeAgentQuery selects a page of the free agent pool
+type FreeAgentQuery struct {
+	Limit  int
+	Offset int
+	// Slots restricts results to players eligible for any of these lineup
+	// slots; empty means all positions
+	Slots  []int
+	SortBy FreeAgentSort
+	// ExcludeWaivers leaves out players currently on waivers
+	ExcludeWaivers bool
+}
+
+// playerFilter is the X-Fantasy-Filter header for kona_player_info
+type playerFilter struct {
+	Players playerFilterOptions `json:"players"`
+}
+
+type playerFilterOptions struct {
+	FilterIDs                      *filterValue `json:"filterIds,omitempty"`
+	FilterStatus                   *filterValue `json:"filterStatus,omitempty"`
+	FilterSlotIDs                  *filterValue `json:"filterSlotIds,omitempty"`
+	FilterStatsForTopScoringPeriod *filterValue `json:"filterStatsForTopScoringPeriodIds,omitempty"`
+	SortPercOwned                  *sortOption  `json:"sortPercOwned,omitempty"`
+	SortAppliedStatTotal           *sortOption  `json:"sortAppliedStatTotal,omitempty"`
+	Limit                          int          `json:"limit,omitempty"`
+	Offset                         int          `json:"offset,omitempty"`
+}
+
+type filterValue struct {
+	Value           interface{} `json:"value"`
+	AdditionalValue []string    `json:"additionalValue,omitempty"`
+}
+
+type sortOption struct {
+	SortAsc      bool   `json:"sortAsc"`
+	SortPriority int    `json:"sortPriority"`
+	Value        string `json:"value,omitempty"`
+}
+
+// splitKey identifies a stat split in filters: source (0 actual, 1
+// projected), split type and season, e.g. "012025" for the last 7 days
+func splitKey(source, splitType, season int) string {
+	return fmt.Sprintf("%d%d%d", source, splitType, season)
+}
+
+// statSplitsFilter asks ESPN to include the season, last 7/15/30 and
+// projected stat splits for season
+func statSplitsFilter(season int) *filterValue {
+	return &filterValue{
+		Value: 2,
+		AdditionalValue: []string{
+			splitKey(0, SplitSeason, season),
+			splitKey(0, SplitLast7, season),
+			splitKey(0, SplitLast15, season),
+			splitKey(0, SplitLast30, season),
+			splitKey(1, SplitSeason, season),
+		},
+	}
+}
+
+func (q FreeAgentQuery) filter(season int) playerFilter {
+	statuses := []string{"FREEAGENT", "WAIVERS"}
+	if q.ExcludeWaivers {
+		statuses = statuses[:1]
+	}
+
+	opts := playerFilterOptions{
+		FilterStatus:                   &filterValue{Value: statuses},
+		FilterStatsForTopScoringPeriod: statSplitsFilter(season),
+		Limit:                          q.Limit,
+		Offset:                         q.Offset,
+	}
+	if len(q.Slots) > 0 {
+		opts.FilterSlotIDs = &filterValue{Value: q.Slots}
+	}
+
+	switch q.SortBy {
+	case SortLast7Points:
+		opts.SortAppliedStatTotal = &sortOption{SortPriority: 1, Value: splitKey(0, SplitLast7, season)}
+		opts.SortPercOwned = &sortOption{SortPriority: 2}
+	default:
+		opts.SortPercOwned = &sortOption{SortPriority: 1}
+	}
+
+	return playerFilter{Players: opts}
+}
+
+// GetFreeAgents fetches a page of the league's free agent pool, with
+// ownership, stat splits and ratings for each player
+func (c *Client) GetFreeAgents(ctx context.Context, q FreeAgentQuery) ([]PoolPlayer, error) {
+	if q.Limit <= 0 {
+		q.Limit = 50
+	}
+
+	players, err := c.getPlayerPool(ctx, q.filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch free agents: %w", err)
+	}
+	return players, nil
+}
+
+// GetPlayerInfo fetches a single player's league pool entry, including
+// injury status and which team (if any) rosters them
+func (c *Client) GetPlayerInfo(ctx context.Context, playerID int) (*PoolPlayer, error) {
+	players, err := c.GetPlayers(ctx, []int{playerID})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range players {
+		if p.ID == playerID || p.Player.ID == playerID {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("player %d not found", playerID)
+}
+
+// GetPlayers fetches the league pool entries for the given player IDs
+func (c *Client) GetPlayers(ctx context.Context, playerIDs []int) ([]PoolPlayer, error) {
+	players, err := c.getPlayerPool(ctx, func(season int) playerFilter {
+		return playerFilter{Players: playerFilterOptions{
+			FilterIDs:                      &filterValue{Value: playerIDs},
+			FilterStatsForTopScoringPeriod: statSplitsFilter(season),
+		}}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch players: %w", err)
+	}
+	return players, nil
+}
+
+// getPlayerPool queries kona_player_info with the filter built for each
+// season, trying the current season first
+func (c *Client) getPlayerPool(ctx context.Context, filter func(season int) playerFilter) ([]PoolPlayer, error) {
+	var lastErr error
+	for _, season := range c.seasons() {
+		header, err := json.Marshal(filter(season))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode player filter: %w", err)
+		}
+
+		url := fmt.Sprintf(
+			"https://fantasy.espn.com/apis/v3/games/fba/seasons/%d/segments/0/leagues/%s?view=kona_player_info",
+			season,
+			c.LeagueID,
+		)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.AddCookie(&http.Cookie{Name: "SWID", Value: c.SWID})
+		req.AddCookie(&http.Cookie{Name: "espn_s2", Value: c.S2})
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Fantasy-Filter", string(header))
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if unauthorized(resp.StatusCode) {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w (status %d)", ErrUnauthorized, resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("ESPN API returned status %d for season %d", resp.StatusCode, season)
+			continue
+		}
+
+		var data struct {
+			Players []PoolPlayer `json:"players"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to decode players (season %d): %w", season, err)
+			continue
+		}
+
+		return data.Players, nil
+	}
+
+	return nil, fmt.Errorf("all seasons failed: %w", lastErr)
+}
@@ -0,0 +1,105 @@
+package espn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned by a CredentialSource when no user with ESPN
+// cookies belongs to the league
+var ErrNoCredentials = errors.New("no ESPN credentials for league")
+
+// Credentials are the ESPN cookies used to read a private league
+type Credentials struct {
+	SWID string
+	S2   string
+}
+
+// CredentialSource looks up the cookies to use for a league
+type CredentialSource interface {
+	LeagueCredentials(ctx context.Context, leagueID string) (Credentials, error)
+}
+
+// Pool hands out one Client per league for the season under way, resolving
+// credentials on first use and again after ttl so credential changes are
+// picked up. Clients are replaced when the season rolls over.
+type Pool struct {
+	source CredentialSource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]pooledClient
+}
+
+type pooledClient struct {
+	client  *Client
+	expires time.Time // zero for pinned clients
+}
+
+// NewPool creates a pool whose clients read the current season
+func NewPool(source CredentialSource, ttl time.Duration) *Pool {
+	return &Pool{
+		source:  source,
+		ttl:     ttl,
+		now:     time.Now,
+		clients: make(map[string]pooledClient),
+	}
+}
+
+// Pin adds a league whose client never expires, such as one configured
+// from the environment. Its cookies carry over into each new season.
+func (p *Pool) Pin(leagueID string, creds Credentials) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clients[leagueID] = pooledClient{client: NewClient(leagueID, CurrentSeason(p.now()), creds.SWID, creds.S2)}
+}
+
+// Client returns the client for leagueID, creating it if needed. It returns
+// ErrNoCredentials when the league is unknown.
+func (p *Pool) Client(ctx context.Context, leagueID string) (*Client, error) {
+	now := p.now()
+	season := CurrentSeason(now)
+
+	p.mu.Lock()
+	pc, ok := p.clients[leagueID]
+	if ok && pc.expires.IsZero() {
+		if pc.client.Season != season {
+			pc.client = NewClient(leagueID, season, pc.client.SWID, pc.client.S2)
+			p.clients[leagueID] = pc
+		}
+		p.mu.Unlock()
+		return pc.client, nil
+	}
+	p.mu.Unlock()
+	if ok && pc.client.Season == season && now.Before(pc.expires) {
+		return pc.client, nil
+	}
+
+	creds, err := p.source.LeagueCredentials(ctx, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(leagueID, season, creds.SWID, creds.S2)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// A pinned client may have been added while credentials were resolved
+	if existing, ok := p.clients[leagueID]; ok && existing.expires.IsZero() {
+		return existing.client, nil
+	}
+	p.clients[leagueID] = pooledClient{client: client, expires: now.Add(p.ttl)}
+	return client, nil
+}
+
+// Invalidate drops a league's client so the next use re-resolves its
+// credentials. Pinned clients are kept.
+func (p *Pool) Invalidate(leagueID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pc, ok := p.clients[leagueID]; ok && !pc.expires.IsZero() {
+		delete(p.clients, leagueID)
+	}
+}
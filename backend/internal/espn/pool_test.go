@@ -0,0 +1,81 @@
+package espn
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingSource hands out the same cookies for every league, counting
+// lookups
+type countingSource struct {
+	lookups int
+}
+
+func (s *countingSource) LeagueCredentials(ctx context.Context, leagueID string) (Credentials, error) {
+	s.lookups++
+	return Credentials{SWID: "{swid}", S2: "s2"}, nil
+}
+
+func TestPoolSeasonRollover(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.September, 30, 23, 0, 0, 0, time.UTC)
+	source := &countingSource{}
+	p := NewPool(source, 24*time.Hour)
+	p.now = func() time.Time { return now }
+	p.Pin("1001", Credentials{SWID: "{env}", S2: "env-s2"})
+
+	pinned, _ := p.Client(ctx, "1001")
+	pooled, _ := p.Client(ctx, "2002")
+	if pinned.Season != 2026 || pooled.Season != 2026 {
+		t.Fatalf("seasons = %d, %d before October, want 2026", pinned.Season, pooled.Season)
+	}
+	if again, _ := p.Client(ctx, "2002"); again != pooled || source.lookups != 1 {
+		t.Errorf("client was not reused within its ttl (%d lookups)", source.lookups)
+	}
+
+	// Two hours later the 2027 season has started, well within the ttl
+	now = now.Add(2 * time.Hour)
+	pinned, _ = p.Client(ctx, "1001")
+	pooled, _ = p.Client(ctx, "2002")
+	if pinned.Season != 2027 || pooled.Season != 2027 {
+		t.Errorf("seasons = %d, %d after the rollover, want 2027", pinned.Season, pooled.Season)
+	}
+	if pinned.SWID != "{env}" || pinned.S2 != "env-s2" {
+		t.Errorf("pinned client lost its cookies: %+v", pinned)
+	}
+	if source.lookups != 2 {
+		t.Errorf("%d credential lookups, want 2", source.lookups)
+	}
+}
+
+func TestPoolExpiresAndInvalidates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2027, time.January, 10, 12, 0, 0, 0, time.UTC)
+	source := &countingSource{}
+	p := NewPool(source, 10*time.Minute)
+	p.now = func() time.Time { return now }
+	p.Pin("1001", Credentials{SWID: "{env}", S2: "env-s2"})
+
+	p.Client(ctx, "2002")
+	now = now.Add(11 * time.Minute)
+	p.Client(ctx, "2002")
+	if source.lookups != 2 {
+		t.Errorf("%d lookups after the ttl, want 2", source.lookups)
+	}
+
+	p.Invalidate("2002")
+	p.Client(ctx, "2002")
+	if source.lookups != 3 {
+		t.Errorf("%d lookups after Invalidate, want 3", source.lookups)
+	}
+
+	pinned, _ := p.Client(ctx, "1001")
+	p.Invalidate("1001")
+	if again, _ := p.Client(ctx, "1001"); again != pinned {
+		t.Error("Invalidate dropped a pinned client")
+	}
+	if source.lookups != 3 {
+		t.Error("a pinned league looked up credentials")
+	}
+}
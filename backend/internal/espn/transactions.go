@@ -0,0 +1,169 @@
+package espn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Activity message type IDs in the league communication feed
+const (
+	msgFreeAgentAdd = 178
+	msgDrop         = 179
+	msgWaiverAdd    = 180
+	msgDropToWaiver = 181
+	msgDropForSlot  = 239
+	msgTrade        = 244
+)
+
+// Move actions
+const (
+	ActionAdd   = "ADD"
+	ActionDrop  = "DROP"
+	ActionTrade = "TRADE"
+)
+
+// Transaction types, from the most significant move in the transaction
+const (
+	TransactionFreeAgent = "FREEAGENT"
+	TransactionWaiver    = "WAIVER"
+	TransactionTrade     = "TRADE"
+	TransactionDrop      = "DROP"
+)
+
+// Transaction is one league activity entry: a free agent or waiver pickup
+// (usually with a drop), a standalone drop, or an accepted trade
+type Transaction struct {
+	ID    string
+	Type  string
+	Date  time.Time
+	Moves []Move
+}
+
+// Move is a single player changing hands within a transaction
+type Move struct {
+	Action   string
+	PlayerID int
+	// TeamID is the team adding, dropping or (for trades) receiving the player
+	TeamID int
+	// FromTeamID is the team giving up a traded player, 0 otherwise
+	FromTeamID int
+	// Waiver is set for adds claimed off waivers
+	Waiver bool
+}
+
+type activityTopic struct {
+	ID       string            `json:"id"`
+	Date     int64             `json:"date"` // epoch milliseconds
+	Messages []activityMessage `json:"messages"`
+}
+
+type activityMessage struct {
+	MessageTypeID int `json:"messageTypeId"`
+	TargetID      int `json:"targetId"` // player ID
+	For           int `json:"for"`
+	From          int `json:"from"`
+	To            int `json:"to"`
+}
+
+// GetTransactions fetches the most recent league transactions, newest first
+func (c *Client) GetTransactions(ctx context.Context, limit int) ([]Transaction, error) {
+	filter := fmt.Sprintf(`{"topics":{"filterType":{"value":["ACTIVITY_TRANSACTIONS"]},"limit":%d,"limitPerMessageSet":{"value":25},"offset":0,"sortMessageDate":{"sortPriority":1,"sortAsc":false},"sortFor":{"sortPriority":2,"sortAsc":false},"filterIncludeMessageTypeIds":{"value":[%d,%d,%d,%d,%d,%d]}}}`,
+		limit, msgFreeAgentAdd, msgDrop, msgWaiverAdd, msgDropToWaiver, msgDropForSlot, msgTrade)
+
+	var lastErr error
+	for _, season := range c.seasons() {
+		url := fmt.Sprintf(
+			"https://fantasy.espn.com/apis/v3/games/fba/seasons/%d/segments/0/leagues/%s/communication/?view=kona_league_communication",
+			season,
+			c.LeagueID,
+		)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.AddCookie(&http.Cookie{Name: "SWID", Value: c.SWID})
+		req.AddCookie(&http.Cookie{Name: "espn_s2", Value: c.S2})
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Fantasy-Filter", filter)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch transactions: %w", err)
+			continue
+		}
+
+		if unauthorized(resp.StatusCode) {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w (status %d)", ErrUnauthorized, resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("ESPN API returned status %d for season %d", resp.StatusCode, season)
+			continue
+		}
+
+		var data struct {
+			Topics []activityTopic `json:"topics"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to decode transactions (season %d): %w", season, err)
+			continue
+		}
+
+		transactions := make([]Transaction, 0, len(data.Topics))
+		for _, topic := range data.Topics {
+			if t, ok := topic.transaction(); ok {
+				transactions = append(transactions, t)
+			}
+		}
+		return transactions, nil
+	}
+
+	return nil, fmt.Errorf("failed to fetch transactions from all seasons: %w", lastErr)
+}
+
+func (t activityTopic) transaction() (Transaction, bool) {
+	tx := Transaction{ID: t.ID, Date: time.UnixMilli(t.Date).UTC()}
+
+	for _, msg := range t.Messages {
+		move := Move{PlayerID: msg.TargetID}
+		switch msg.MessageTypeID {
+		case msgFreeAgentAdd, msgWaiverAdd:
+			move.Action, move.TeamID = ActionAdd, msg.To
+			move.Waiver = msg.MessageTypeID == msgWaiverAdd
+		case msgDrop, msgDropToWaiver:
+			move.Action, move.TeamID = ActionDrop, msg.To
+		case msgDropForSlot:
+			move.Action, move.TeamID = ActionDrop, msg.For
+		case msgTrade:
+			move.Action, move.TeamID, move.FromTeamID = ActionTrade, msg.To, msg.From
+		default:
+			continue
+		}
+		tx.Moves = append(tx.Moves, move)
+	}
+	if len(tx.Moves) == 0 {
+		return tx, false
+	}
+
+	tx.Type = TransactionDrop
+	for _, m := range tx.Moves {
+		switch {
+		case m.Action == ActionTrade:
+			tx.Type = TransactionTrade
+		case m.Action == ActionAdd && m.Waiver && tx.Type != TransactionTrade:
+			tx.Type = TransactionWaiver
+		case m.Action == ActionAdd && tx.Type == TransactionDrop:
+			tx.Type = TransactionFreeAgent
+		}
+	}
+	return tx, true
+}
@@ -0,0 +1,147 @@
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldMap strips diacritics common in NBA player names so "Jokic" matches "Jokić"
+var foldMap = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a', 'ā': 'a',
+	'ć': 'c', 'č': 'c', 'ç': 'c',
+	'đ': 'd',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ė': 'e',
+	'ğ': 'g',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i', 'ı': 'i',
+	'ł': 'l',
+	'ñ': 'n', 'ń': 'n',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o', 'ø': 'o',
+	'ř': 'r',
+	'ś': 's', 'š': 's', 'ş': 's',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u', 'ů': 'u',
+	'ý': 'y',
+	'ž': 'z', 'ź': 'z', 'ż': 'z',
+}
+
+// Normalize lowercases s, folds diacritics, drops punctuation and collapses
+// whitespace
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if f, ok := foldMap[r]; ok {
+			r = f
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Score rates how well query matches candidate, from 0 (no match) to 1
+// (exact match after normalization)
+func Score(query, candidate string) float64 {
+	q := Normalize(query)
+	c := Normalize(candidate)
+	if q == "" || c == "" {
+		return 0
+	}
+	if q == c {
+		return 1
+	}
+	if strings.HasPrefix(c, q) {
+		return 0.95
+	}
+
+	qTokens := strings.Fields(q)
+	cTokens := strings.Fields(c)
+	if tokensArePrefixes(qTokens, cTokens) {
+		return 0.9
+	}
+
+	best := similarity(q, c)
+
+	// Compare each query token against its closest candidate token so a
+	// misspelled last name alone still finds the player
+	var sum float64
+	for _, qt := range qTokens {
+		var m float64
+		for _, ct := range cTokens {
+			if s := similarity(qt, ct); s > m {
+				m = s
+			}
+		}
+		sum += m
+	}
+	if tokenScore := 0.85 * sum / float64(len(qTokens)); tokenScore > best {
+		best = tokenScore
+	}
+
+	return best
+}
+
+// tokensArePrefixes reports whether every query token starts some candidate token
+func tokensArePrefixes(query, candidate []string) bool {
+	for _, qt := range query {
+		found := false
+		for _, ct := range candidate {
+			if strings.HasPrefix(ct, qt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity is 1 minus the Levenshtein distance normalized by the longer string
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,139 @@
+// Package logging sets up structured JSON logging with log/slog and carries
+// per-request fields, such as the request ID, through contexts so that
+// everything logged while serving a request can be correlated.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Setup makes a slog handler writing to w the default logger. format is
+// "json" or "text" and level one of debug, info, warn or error. The log
+// package's default logger writes through the same handler at info level.
+func Setup(w io.Writer, format, level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q: must be json or text", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// ParseLevel parses a level name such as "info"
+func ParseLevel(s string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q: must be debug, info, warn or error", s)
+	}
+	return lvl, nil
+}
+
+// Milliseconds converts d to fractional milliseconds for latency fields
+func Milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+type fieldsKey struct{}
+
+// fields holds the attributes added to every record logged with a context.
+// It is shared by the contexts derived from the one With returned, so Add
+// from deep inside a request shows up on the request's own log record.
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// With returns a context whose log records carry args, as key-value pairs,
+// on top of the fields ctx already carries
+func With(ctx context.Context, args ...any) context.Context {
+	f := &fields{attrs: Attrs(ctx)}
+	f.add(args)
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// Add sets fields on the context's existing set, replacing any with the
+// same key. It does nothing when ctx has none.
+func Add(ctx context.Context, args ...any) {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.add(args)
+	}
+}
+
+// Attrs returns the fields ctx carries
+func Attrs(ctx context.Context) []slog.Attr {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+func (f *fields) add(args []any) {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r.Attrs(func(a slog.Attr) bool {
+		for i := range f.attrs {
+			if f.attrs[i].Key == a.Key {
+				f.attrs[i] = a
+				return true
+			}
+		}
+		f.attrs = append(f.attrs, a)
+		return true
+	})
+}
+
+// contextHandler adds the fields carried by a record's context, except
+// those the record already sets itself
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := Attrs(ctx)
+	if len(attrs) == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	set := make(map[string]bool, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		set[a.Key] = true
+		return true
+	})
+	r = r.Clone()
+	for _, a := range attrs {
+		if !set[a.Key] {
+			r.AddAttrs(a)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
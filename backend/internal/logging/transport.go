@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Transport logs every request sent through it with the upstream's name,
+// status and latency: failures and error statuses at warn, the rest at
+// debug. The upstream is also added to the caller's context fields, so a
+// request's own log record names the upstream it waited on.
+type Transport struct {
+	// Upstream names the service in logs, e.g. "espn"
+	Upstream string
+	// Next sends the requests (default http.DefaultTransport)
+	Next http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	Add(req.Context(), "upstream", t.Upstream)
+	ctx := With(req.Context(), "upstream", t.Upstream)
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+
+	// The query string is left out since it can carry filters or tokens
+	attrs := []any{
+		"method", req.Method,
+		"url", req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		"latency_ms", Milliseconds(time.Since(start)),
+	}
+	switch {
+	case err != nil:
+		slog.WarnContext(ctx, "upstream request failed", append(attrs, "error", err)...)
+	case resp.StatusCode >= 400:
+		slog.WarnContext(ctx, "upstream request failed", append(attrs, "status", resp.StatusCode)...)
+	default:
+		slog.DebugContext(ctx, "upstream request", append(attrs, "status", resp.StatusCode)...)
+	}
+	return resp, err
+}
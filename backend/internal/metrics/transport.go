@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	upstreamRequests = Default.NewCounterVec("swishradar_upstream_requests_total",
+		`Requests to upstream services by response status, or "error" when none arrived.`, "upstream", "status")
+	upstreamDuration = Default.NewHistogramVec("swishradar_upstream_request_duration_seconds",
+		"Latency of requests to upstream services.", DefaultBuckets, "upstream")
+)
+
+// Transport counts and times every request sent through it. Each retry is
+// a request of its own, so a rise in 429s or 5xx from ESPN shows up even
+// when retries hide it from callers.
+type Transport struct {
+	// Upstream names the service in the upstream label, e.g. "espn"
+	Upstream string
+	// Next sends the requests (default http.DefaultTransport)
+	Next http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	upstreamDuration.Observe(time.Since(start).Seconds(), t.Upstream)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamRequests.Inc(t.Upstream, status)
+	return resp, err
+}
@@ -16,6 +16,7 @@ type League struct {
 type Team struct {
 	ID         int       `json:"id" db:"id"`
 	LeagueID   string    `json:"league_id" db:"league_id"`
+	ESPNTeamID *int      `json:"espn_team_id" db:"espn_team_id"`
 	OwnerID    *int      `json:"owner_id" db:"owner_id"`
 	TeamName   string    `json:"team_name" db:"team_name"`
 	RosterJSON string    `json:"roster_json" db:"roster_json"`
@@ -23,13 +24,23 @@ type Team struct {
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// RosterSlot is one player in a team's roster_json
+type RosterSlot struct {
+	ESPNID int    `json:"espn_id"`
+	Name   string `json:"name"`
+}
+
 // User represents a platform user
 type User struct {
-	ID          int       `json:"id" db:"id"`
-	Email       string    `json:"email" db:"email"`
-	DisplayName string    `json:"display_name" db:"display_name"`
-	ESPNSWID    *string   `json:"-" db:"espn_swid"` // Never expose in JSON
-	ESPNS2      *string   `json:"-" db:"espn_s2"`   // Never expose in JSON
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int     `json:"id" db:"id"`
+	Email       string  `json:"email" db:"email"`
+	DisplayName string  `json:"display_name" db:"display_name"`
+	ESPNSWID    *string `json:"-" db:"espn_swid"` // Never expose in JSON
+	ESPNS2      *string `json:"-" db:"espn_s2"`   // Never expose in JSON
+	// ESPNStatus is "valid" once the cookies have been checked against ESPN
+	// and "expired" after ESPN starts rejecting them
+	ESPNStatus    *string    `json:"espn_status,omitempty" db:"espn_status"`
+	ESPNCheckedAt *time.Time `json:"espn_checked_at,omitempty" db:"espn_checked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
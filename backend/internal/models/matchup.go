@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Matchup is a head-to-head matchup for one scoring period. Team scores are
+// categories won; CategoryResults and PredictedCategories hold the
+// per-category values.
+type Matchup struct {
+	ID                  int              `json:"id" db:"id"`
+	LeagueID            string           `json:"league_id" db:"league_id"`
+	Week                int              `json:"week" db:"week"`
+	Season              int              `json:"season" db:"season"`
+	Team1ID             int              `json:"team1_id" db:"team1_id"`
+	Team2ID             int              `json:"team2_id" db:"team2_id"`
+	Team1Name           string           `json:"team1_name" db:"-"`
+	Team2Name           string           `json:"team2_name" db:"-"`
+	Team1Score          *float64         `json:"team1_score" db:"team1_score"`
+	Team2Score          *float64         `json:"team2_score" db:"team2_score"`
+	PredictedWinner     *int             `json:"predicted_winner" db:"predicted_winner"`
+	ActualWinner        *int             `json:"actual_winner" db:"actual_winner"`
+	PeriodStart         *time.Time       `json:"period_start" db:"period_start"`
+	PeriodEnd           *time.Time       `json:"period_end" db:"period_end"`
+	CategoryResults     []CategoryResult `json:"category_results" db:"category_results"`
+	PredictedCategories []CategoryResult `json:"predicted_categories" db:"predicted_categories"`
+	RecapPostedAt       *time.Time       `json:"recap_posted_at" db:"recap_posted_at"`
+	CreatedAt           time.Time        `json:"created_at" db:"created_at"`
+}
+
+// CategoryResult is both teams' value in one category
+type CategoryResult struct {
+	Category string  `json:"category"`
+	Team1    float64 `json:"team1"`
+	Team2    float64 `json:"team2"`
+}
+
+// TeamMVP is a fantasy team's top performer over a date range
+type TeamMVP struct {
+	PlayerID     int     `json:"player_id"`
+	Name         string  `json:"name"`
+	GamesPlayed  int     `json:"games_played"`
+	FantasyValue float64 `json:"fantasy_value"`
+}
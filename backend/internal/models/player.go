@@ -46,3 +46,19 @@ type StreamingRecommendation struct {
 	OpportunityFactor float64 `json:"opportunity_factor"`
 	Reason            string  `json:"reason"`
 }
+
+// PlayerAverages summarizes per-game production over a date range
+type PlayerAverages struct {
+	GamesPlayed  int     `json:"games_played"`
+	Points       float64 `json:"points"`
+	Rebounds     float64 `json:"rebounds"`
+	Assists      float64 `json:"assists"`
+	Steals       float64 `json:"steals"`
+	Blocks       float64 `json:"blocks"`
+	Turnovers    float64 `json:"turnovers"`
+	ThreesMade   float64 `json:"threes_made"`
+	FGPct        float64 `json:"fg_pct"`
+	FTPct        float64 `json:"ft_pct"`
+	Minutes      float64 `json:"minutes"`
+	FantasyValue float64 `json:"fantasy_value"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Transaction is a league move ingested from ESPN: a free agent or waiver
+// pickup, a drop or a trade
+type Transaction struct {
+	ID          int               `json:"id" db:"id"`
+	LeagueID    string            `json:"league_id" db:"league_id"`
+	ESPNID      string            `json:"espn_id" db:"espn_id"`
+	Type        string            `json:"type" db:"type"`
+	OccurredAt  time.Time         `json:"occurred_at" db:"occurred_at"`
+	Moves       []TransactionMove `json:"moves" db:"moves"`
+	AnnouncedAt *time.Time        `json:"announced_at" db:"announced_at"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+}
+
+// TransactionMove is one player changing hands in a transaction
+type TransactionMove struct {
+	Action       string `json:"action"`
+	PlayerESPNID int    `json:"player_espn_id"`
+	PlayerName   string `json:"player_name"`
+	TeamESPNID   int    `json:"team_espn_id"`
+	TeamName     string `json:"team_name"`
+	// FromTeamESPNID and FromTeamName are set for traded players
+	FromTeamESPNID int    `json:"from_team_espn_id,omitempty"`
+	FromTeamName   string `json:"from_team_name,omitempty"`
+	Waiver         bool   `json:"waiver,omitempty"`
+}
@@ -1,18 +1,31 @@
 package nba
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+	"github.com/milindkumar1/swishradar/internal/ratelimit"
 )
 
+// Host is where NBA Stats API requests go, for ratelimit.SetHostLimit
+const Host = "stats.nba.com"
+
 const (
-	baseURL   = "https://stats.nba.com/stats"
+	baseURL   = "https://" + Host + "/stats"
 	userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
 )
 
+// transport is shared by every Client so they draw on one rate limit
+var transport = &ratelimit.Transport{
+	Next: &metrics.Transport{Upstream: "nba", Next: &logging.Transport{Upstream: "nba"}},
+}
+
 // Client handles NBA Stats API requests
 type Client struct {
 	client *http.Client
@@ -22,7 +35,8 @@ type Client struct {
 func NewClient() *Client {
 	return &Client{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
@@ -55,10 +69,10 @@ type PlayerGameLog struct {
 }
 
 // GetPlayerGameLog fetches game logs for a specific player
-func (c *Client) GetPlayerGameLog(playerID string, season string) ([]PlayerGameLog, error) {
+func (c *Client) GetPlayerGameLog(ctx context.Context, playerID string, season string) ([]PlayerGameLog, error) {
 	url := fmt.Sprintf("%s/playergamelog", baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -96,10 +110,10 @@ func (c *Client) GetPlayerGameLog(playerID string, season string) ([]PlayerGameL
 }
 
 // GetTeamSchedule fetches the schedule for a team
-func (c *Client) GetTeamSchedule(teamID string, season string) ([]interface{}, error) {
+func (c *Client) GetTeamSchedule(ctx context.Context, teamID string, season string) ([]interface{}, error) {
 	url := fmt.Sprintf("%s/teamgamelog", baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -133,10 +147,10 @@ func (c *Client) GetTeamSchedule(teamID string, season string) ([]interface{}, e
 }
 
 // GetAllPlayers fetches the list of all active NBA players
-func (c *Client) GetAllPlayers(season string) ([]interface{}, error) {
+func (c *Client) GetAllPlayers(ctx context.Context, season string) ([]interface{}, error) {
 	url := fmt.Sprintf("%s/commonallplayers", baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
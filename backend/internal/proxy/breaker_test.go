@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBreaker drives a breaker with a fake clock through its states
+func TestBreaker(t *testing.T) {
+	type step struct {
+		after   time.Duration // advance the clock first
+		op      string        // "allow", "ok", "fail" or "release"
+		allowed bool          // for "allow"
+		state   string        // state after the step
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after the threshold",
+			steps: []step{
+				{op: "allow", allowed: true, state: "closed"},
+				{op: "fail", state: "closed"},
+				{op: "fail", state: "closed"},
+				{op: "fail", state: "open"},
+				{op: "allow", allowed: false, state: "open"},
+				{after: 9 * time.Second, op: "allow", allowed: false, state: "open"},
+			},
+		},
+		{
+			name: "success resets the count",
+			steps: []step{
+				{op: "fail", state: "closed"},
+				{op: "fail", state: "closed"},
+				{op: "ok", state: "closed"},
+				{op: "fail", state: "closed"},
+				{op: "fail", state: "closed"},
+				{op: "allow", allowed: true, state: "closed"},
+			},
+		},
+		{
+			name: "half-open trial succeeds",
+			steps: []step{
+				{op: "fail"}, {op: "fail"}, {op: "fail", state: "open"},
+				{after: 10 * time.Second, op: "allow", allowed: true, state: "half-open"},
+				{op: "allow", allowed: false, state: "half-open"},
+				{op: "ok", state: "closed"},
+				{op: "allow", allowed: true, state: "closed"},
+				{op: "allow", allowed: true, state: "closed"},
+			},
+		},
+		{
+			name: "half-open trial fails",
+			steps: []step{
+				{op: "fail"}, {op: "fail"}, {op: "fail", state: "open"},
+				{after: 10 * time.Second, op: "allow", allowed: true, state: "half-open"},
+				{op: "fail", state: "open"},
+				{op: "allow", allowed: false, state: "open"},
+				{after: 9 * time.Second, op: "allow", allowed: false, state: "open"},
+				{after: time.Second, op: "allow", allowed: true, state: "half-open"},
+			},
+		},
+		{
+			name: "released trial frees the slot",
+			steps: []step{
+				{op: "fail"}, {op: "fail"}, {op: "fail", state: "open"},
+				{after: 10 * time.Second, op: "allow", allowed: true, state: "half-open"},
+				{op: "release", state: "half-open"},
+				{op: "allow", allowed: true, state: "half-open"},
+				{op: "allow", allowed: false, state: "half-open"},
+			},
+		},
+		{
+			name: "release while closed",
+			steps: []step{
+				{op: "allow", allowed: true, state: "closed"},
+				{op: "release", state: "closed"},
+				{op: "allow", allowed: true, state: "closed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1_800_000_000, 0)
+			b := NewBreaker(3, 10*time.Second)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				switch s.op {
+				case "allow":
+					if err := b.Allow(); (err == nil) != s.allowed {
+						t.Fatalf("step %d: Allow = %v, want allowed %v", i, err, s.allowed)
+					}
+				case "ok":
+					b.Record(true)
+				case "fail":
+					b.Record(false)
+				case "release":
+					b.Release()
+				}
+				if s.state != "" && b.State() != s.state {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, s.op, b.State(), s.state)
+				}
+			}
+		})
+	}
+}
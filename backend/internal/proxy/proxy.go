@@ -0,0 +1,278 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/logging"
+	"github.com/milindkumar1/swishradar/internal/metrics"
+)
+
+// Options configures a Proxy. Zero values use the defaults noted on each field.
+type Options struct {
+	// Name identifies the upstream in error bodies and logs
+	Name string
+	// Retries is how many extra attempts idempotent GETs get (default 2)
+	Retries int
+	// RetryBackoff is the base delay between attempts, doubled each time
+	// and jittered (default 200ms)
+	RetryBackoff time.Duration
+	// FailureThreshold is how many consecutive failures open the circuit
+	// breaker (default 5)
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before a trial request
+	// (default 30s)
+	Cooldown time.Duration
+	// Transport performs the upstream requests (default: a clone of
+	// http.DefaultTransport that logs and counts each attempt)
+	Transport http.RoundTripper
+}
+
+// Proxy forwards requests to a single upstream service
+type Proxy struct {
+	name    string
+	target  *url.URL
+	breaker *Breaker
+	// transport reaches the upstream without retries or the breaker
+	transport http.RoundTripper
+	rp        *httputil.ReverseProxy
+}
+
+type upstreamPathKey struct{}
+
+// New creates a proxy for the upstream at target
+func New(target string, opts Options) (*Proxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream URL %q: %w", target, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q: scheme and host are required", target)
+	}
+
+	if opts.Name == "" {
+		opts.Name = u.Host
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 2
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = 200 * time.Millisecond
+	}
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown == 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.Transport == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.MaxIdleConnsPerHost = 20
+		opts.Transport = &metrics.Transport{
+			Upstream: opts.Name,
+			Next:     &logging.Transport{Upstream: opts.Name, Next: t},
+		}
+	}
+
+	p := &Proxy{
+		name:      opts.Name,
+		target:    u,
+		breaker:   NewBreaker(opts.FailureThreshold, opts.Cooldown),
+		transport: opts.Transport,
+	}
+
+	p.rp = &httputil.ReverseProxy{
+		Rewrite: p.rewrite,
+		Transport: &resilientTransport{
+			next:    opts.Transport,
+			breaker: p.breaker,
+			retries: opts.Retries,
+			backoff: opts.RetryBackoff,
+		},
+		ErrorHandler: p.handleError,
+	}
+
+	return p, nil
+}
+
+// Route returns a handler that proxies to path on the upstream, keeping the
+// caller's query string and headers other than its credentials. The
+// upstream call is bound to the caller's context and cancelled after
+// timeout.
+func (p *Proxy) Route(path string, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		ctx = context.WithValue(ctx, upstreamPathKey{}, path)
+		p.rp.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Check requests path on the upstream once and reports an error unless it
+// answers 2xx. It skips the retries and the circuit breaker, so it reports
+// on the upstream as it is now and never takes a half-open breaker's trial.
+func (p *Proxy) Check(ctx context.Context, path string) error {
+	u := *p.target
+	u.Path = strings.TrimSuffix(p.target.Path, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", p.name, resp.StatusCode)
+	}
+	return nil
+}
+
+// BreakerState reports the upstream circuit breaker state
+func (p *Proxy) BreakerState() string {
+	return p.breaker.State()
+}
+
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	path, _ := pr.In.Context().Value(upstreamPathKey{}).(string)
+
+	pr.Out.URL.Scheme = p.target.Scheme
+	pr.Out.URL.Host = p.target.Host
+	pr.Out.URL.Path = strings.TrimSuffix(p.target.Path, "/") + path
+	pr.Out.URL.RawPath = ""
+	pr.Out.URL.RawQuery = pr.In.URL.RawQuery
+	pr.Out.Host = ""
+	pr.SetXForwarded()
+
+	// The caller's credentials are for this API, not the upstream. Cached
+	// routes replay them in background refreshes too.
+	pr.Out.Header.Del("Authorization")
+	pr.Out.Header.Del("Cookie")
+}
+
+// handleError answers failed proxy requests with a JSON body in the API's
+// usual {"error": ...} shape plus a machine-readable code
+func (p *Proxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, message := http.StatusBadGateway, "upstream_unavailable", fmt.Sprintf("Failed to connect to %s", p.name)
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		status, code, message = http.StatusServiceUnavailable, "circuit_open", fmt.Sprintf("%s is unavailable, try again shortly", p.name)
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code, message = http.StatusGatewayTimeout, "upstream_timeout", fmt.Sprintf("%s timed out", p.name)
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		// The caller went away; there is nobody to answer
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "error proxying request", "upstream", p.name, "code", code, "error", err)
+	logging.Add(r.Context(), "upstream", p.name, "error", err.Error())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":    message,
+		"code":     code,
+		"upstream": p.name,
+	})
+}
+
+// resilientTransport retries idempotent requests and feeds the circuit
+// breaker with the outcome of every attempt. Transport errors, timeouts,
+// 502 and 504 count as failures.
+type resilientTransport struct {
+	next    http.RoundTripper
+	breaker *Breaker
+	retries int
+	backoff time.Duration
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += t.retries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(req.Context(), t.backoff<<(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := t.breaker.Allow(); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			if req.Context().Err() != nil {
+				// Timeouts count against the upstream, caller cancellations don't
+				if errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+					t.breaker.Record(false)
+				} else {
+					t.breaker.Release()
+				}
+				return nil, req.Context().Err()
+			}
+			t.breaker.Record(false)
+			lastErr = err
+			continue
+		}
+
+		// Only gateway errors mean the upstream can't be reached. Other 5xx
+		// come from a working service, like the ESPN service's 503 when a
+		// league has no credentials, and mustn't trip the breaker for
+		// every caller.
+		t.breaker.Record(!isGatewayFailure(resp.StatusCode))
+		if isRetryableStatus(resp.StatusCode) && attempt < attempts-1 {
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+func isIdempotent(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+func isGatewayFailure(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusGatewayTimeout
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// sleep waits for d plus up to 50% jitter, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	d += time.Duration(rand.Int63n(int64(d)/2 + 1))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
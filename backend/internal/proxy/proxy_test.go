@@ -0,0 +1,327 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upstreamFunc answers requests in place of the upstream
+type upstreamFunc func(req *http.Request) (*http.Response, error)
+
+func (f upstreamFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// answers returns an upstream giving each status in turn, 0 meaning a
+// connection error, then 200; hits counts the calls
+func answers(hits *atomic.Int32, statuses ...int) upstreamFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		n := int(hits.Add(1))
+		status := http.StatusOK
+		if n <= len(statuses) {
+			status = statuses[n-1]
+		}
+		if status == 0 {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	}
+}
+
+// hang returns an upstream that answers only when the request's context is
+// done
+func hang() upstreamFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+}
+
+func TestRouteStripsCredentials(t *testing.T) {
+	var got http.Header
+	var path, query string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, path, query = r.Header.Clone(), r.URL.Path, r.URL.RawQuery
+	}))
+	defer upstream.Close()
+
+	p, err := New(upstream.URL+"/base", Options{Name: "espn-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/espn/free-agents?limit=10", nil)
+	req.Header.Set("Authorization", "Bearer sr_secret")
+	req.Header.Set("Cookie", "sb-access-token=jwt")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-Id", "abc")
+	w := httptest.NewRecorder()
+	p.Route("/api/free-agents", time.Second).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if path != "/base/api/free-agents" || query != "limit=10" {
+		t.Errorf("upstream got %s?%s, want /base/api/free-agents?limit=10", path, query)
+	}
+	tests := []struct {
+		header, want string
+	}{
+		{"Authorization", ""},
+		{"Cookie", ""},
+		{"Accept", "application/json"},
+		{"X-Request-Id", "abc"},
+	}
+	for _, tt := range tests {
+		if v := got.Get(tt.header); v != tt.want {
+			t.Errorf("upstream %s = %q, want %q", tt.header, v, tt.want)
+		}
+	}
+}
+
+func TestResilientTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		statuses   []int
+		wantHits   int32
+		wantStatus int // 0 for an error
+		wantState  string
+	}{
+		{"ok", http.MethodGet, "", nil, 1, 200, "closed"},
+		{"bad gateway then ok", http.MethodGet, "", []int{502}, 2, 200, "closed"},
+		{"HEAD is retried", http.MethodHead, "", []int{504, 504}, 3, 200, "closed"},
+		{"connection error then ok", http.MethodGet, "", []int{0}, 2, 200, "closed"},
+		{"unavailable is retried without tripping", http.MethodGet, "", []int{503, 503, 503}, 3, 503, "closed"},
+		{"gateway failures trip", http.MethodGet, "", []int{502, 502, 504}, 3, 504, "open"},
+		{"connection errors trip", http.MethodGet, "", []int{0, 0, 0}, 3, 0, "open"},
+		{"server error is not retried", http.MethodGet, "", []int{500}, 1, 500, "closed"},
+		{"client error is not retried", http.MethodGet, "", []int{404}, 1, 404, "closed"},
+		{"POST is not retried", http.MethodPost, "", []int{502}, 1, 502, "closed"},
+		{"GET with a body is not retried", http.MethodGet, "{}", []int{502}, 1, 502, "closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			tr := &resilientTransport{
+				next:    answers(&hits, tt.statuses...),
+				breaker: NewBreaker(3, time.Minute),
+				retries: 2,
+				backoff: time.Millisecond,
+			}
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, "http://espn-service/api/league", body)
+			resp, err := tr.RoundTrip(req)
+
+			if tt.wantStatus == 0 {
+				if err == nil {
+					t.Fatalf("status = %d, want an error", resp.StatusCode)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("upstream hit %d times, want %d", got, tt.wantHits)
+			}
+			if got := tr.breaker.State(); got != tt.wantState {
+				t.Errorf("breaker %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestResilientTransportOpenCircuit(t *testing.T) {
+	var hits atomic.Int32
+	tr := &resilientTransport{next: answers(&hits), breaker: NewBreaker(1, time.Minute), retries: 2, backoff: time.Millisecond}
+	tr.breaker.Record(false)
+
+	req := httptest.NewRequest(http.MethodGet, "http://espn-service/api/league", nil)
+	if _, err := tr.RoundTrip(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+	if hits.Load() != 0 {
+		t.Error("an open circuit reached the upstream")
+	}
+}
+
+func TestResilientTransportContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       func() (context.Context, context.CancelFunc)
+		wantErr   error
+		wantState string
+	}{
+		{
+			name: "timeout counts as a failure",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantErr:   context.DeadlineExceeded,
+			wantState: "open",
+		},
+		{
+			name: "caller cancelling doesn't",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr:   context.Canceled,
+			wantState: "closed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &resilientTransport{next: hang(), breaker: NewBreaker(1, time.Minute), retries: 2, backoff: time.Millisecond}
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://espn-service/api/league", nil).WithContext(ctx)
+			if _, err := tr.RoundTrip(req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := tr.breaker.State(); got != tt.wantState {
+				t.Errorf("breaker %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		upstream   func(hits *atomic.Int32) http.RoundTripper
+		before     int // requests to make first
+		timeout    time.Duration
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "unreachable",
+			upstream:   func(hits *atomic.Int32) http.RoundTripper { return answers(hits, 0, 0, 0) },
+			timeout:    time.Second,
+			wantStatus: http.StatusBadGateway,
+			wantCode:   "upstream_unavailable",
+		},
+		{
+			name:       "circuit open",
+			upstream:   func(hits *atomic.Int32) http.RoundTripper { return answers(hits, 0, 0, 0) },
+			before:     1,
+			timeout:    time.Second,
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "circuit_open",
+		},
+		{
+			name:       "timeout",
+			upstream:   func(hits *atomic.Int32) http.RoundTripper { return hang() },
+			timeout:    10 * time.Millisecond,
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "upstream_timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			p, err := New("http://espn-service", Options{
+				Name:             "ESPN service",
+				RetryBackoff:     time.Millisecond,
+				FailureThreshold: 3,
+				Transport:        tt.upstream(&hits),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			route := p.Route("/api/league", tt.timeout)
+			serve := func() *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/espn/league", nil))
+				return w
+			}
+			for i := 0; i < tt.before; i++ {
+				serve()
+			}
+
+			w := serve()
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["code"] != tt.wantCode || body["upstream"] != "ESPN service" || body["error"] == "" {
+				t.Errorf("body = %v, want code %s", body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRouteCallerGone(t *testing.T) {
+	p, err := New("http://espn-service", Options{Transport: hang()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	w := httptest.NewRecorder()
+	p.Route("/api/league", time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/espn/league", nil).WithContext(ctx))
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("answered a caller that went away: %d %s", w.Code, w.Body)
+	}
+	if got := p.BreakerState(); got != "closed" {
+		t.Errorf("breaker %s, want closed", got)
+	}
+}
+
+func TestCheckBypassesBreaker(t *testing.T) {
+	var hits atomic.Int32
+	p, err := New("http://espn-service", Options{
+		RetryBackoff:     time.Millisecond,
+		FailureThreshold: 1,
+		Transport:        answers(&hits, 0, 502),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed request opens the breaker
+	w := httptest.NewRecorder()
+	p.Route("/api/league", time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/espn/league", nil))
+	if p.BreakerState() != "open" {
+		t.Fatalf("breaker %s, want open", p.BreakerState())
+	}
+
+	// Check reaches the upstream anyway, once, without retrying the 502
+	if err := p.Check(context.Background(), "/health"); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Check = %v, want the 502", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("upstream hit %d times by Check, want 1", got-1)
+	}
+	if err := p.Check(context.Background(), "/health"); err != nil {
+		t.Errorf("Check = %v, want nil", err)
+	}
+	if p.BreakerState() != "open" {
+		t.Errorf("Check changed the breaker to %s", p.BreakerState())
+	}
+}
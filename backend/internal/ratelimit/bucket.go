@@ -0,0 +1,124 @@
+// Package ratelimit paces requests with token buckets: outbound, so calls
+// to ESPN and the NBA API stay under their throttling limits, and inbound,
+// so one client can't monopolize the API.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket that refills at a steady rate up to its burst
+// size. A nil *Bucket never limits.
+type Bucket struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket allowing perMinute requests a minute on
+// average and up to burst at once. It returns nil, no limit, when perMinute
+// is not positive.
+func NewBucket(perMinute, burst int) *Bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{
+		rate:   float64(perMinute) / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token, sleeping until one is available or ctx is done. It
+// returns how long it waited.
+func (b *Bucket) Wait(ctx context.Context) (time.Duration, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	var waited time.Duration
+	for {
+		delay := b.take()
+		if delay == 0 {
+			return waited, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			waited += delay
+		case <-ctx.Done():
+			timer.Stop()
+			return waited, ctx.Err()
+		}
+	}
+}
+
+// take removes a token and returns 0, or returns how long until one is due
+func (b *Bucket) take() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return b.until(1)
+}
+
+// allow takes a token if one is available, without waiting. It returns
+// whether it did, the whole tokens left, how long until the next token and
+// how long until the bucket is full again.
+func (b *Bucket) allow(now time.Time) (ok bool, remaining int, next, full time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		ok = true
+	} else {
+		next = b.until(1)
+	}
+	return ok, int(b.tokens), next, b.until(b.burst)
+}
+
+// idle reports whether the bucket has refilled completely, so dropping it
+// loses nothing
+func (b *Bucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// until returns how long until the bucket holds n tokens
+func (b *Bucket) until(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *Bucket) refill(now time.Time) {
+	// Callers read the clock before locking, so now can trail b.last
+	if !now.After(b.last) {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
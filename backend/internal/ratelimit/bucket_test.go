@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewBucket(t *testing.T) {
+	if b := NewBucket(0, 5); b != nil {
+		t.Error("NewBucket(0, 5) should be nil, no limit")
+	}
+	if b := NewBucket(-1, 5); b != nil {
+		t.Error("NewBucket(-1, 5) should be nil, no limit")
+	}
+	if b := NewBucket(60, 0); b == nil || b.burst != 1 {
+		t.Error("NewBucket(60, 0) should allow bursts of 1")
+	}
+
+	var b *Bucket
+	if waited, err := b.Wait(context.Background()); waited != 0 || err != nil {
+		t.Errorf("nil bucket Wait = %v, %v; want 0, nil", waited, err)
+	}
+}
+
+// TestBucketAllow drives a bucket with a fake clock: each step happens at
+// an offset from when the bucket was created
+func TestBucketAllow(t *testing.T) {
+	type step struct {
+		at        time.Duration
+		ok        bool
+		remaining int
+		next      time.Duration
+		full      time.Duration
+	}
+	tests := []struct {
+		name             string
+		perMinute, burst int
+		steps            []step
+	}{
+		{
+			name:      "burst then throttle",
+			perMinute: 60, burst: 3,
+			steps: []step{
+				{at: 0, ok: true, remaining: 2, full: time.Second},
+				{at: 0, ok: true, remaining: 1, full: 2 * time.Second},
+				{at: 0, ok: true, remaining: 0, full: 3 * time.Second},
+				{at: 0, ok: false, remaining: 0, next: time.Second, full: 3 * time.Second},
+			},
+		},
+		{
+			name:      "refills at the rate",
+			perMinute: 60, burst: 1,
+			steps: []step{
+				{at: 0, ok: true, remaining: 0, full: time.Second},
+				{at: 500 * time.Millisecond, ok: false, next: 500 * time.Millisecond, full: 500 * time.Millisecond},
+				{at: time.Second, ok: true, remaining: 0, full: time.Second},
+			},
+		},
+		{
+			name:      "refill is capped at burst",
+			perMinute: 120, burst: 2,
+			steps: []step{
+				{at: 0, ok: true, remaining: 1, full: 500 * time.Millisecond},
+				{at: time.Hour, ok: true, remaining: 1, full: 500 * time.Millisecond},
+				{at: time.Hour, ok: true, remaining: 0, full: time.Second},
+				{at: time.Hour, ok: false, next: 500 * time.Millisecond, full: time.Second},
+			},
+		},
+		{
+			name:      "clock going backwards",
+			perMinute: 60, burst: 1,
+			steps: []step{
+				{at: time.Second, ok: true, remaining: 0, full: time.Second},
+				{at: 0, ok: false, next: time.Second, full: time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBucket(tt.perMinute, tt.burst)
+			start := b.last
+			for i, s := range tt.steps {
+				ok, remaining, next, full := b.allow(start.Add(s.at))
+				if ok != s.ok || remaining != s.remaining || next != s.next || full != s.full {
+					t.Errorf("step %d at %v: allow = %v, %d, %v, %v; want %v, %d, %v, %v",
+						i, s.at, ok, remaining, next, full, s.ok, s.remaining, s.next, s.full)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketIdle(t *testing.T) {
+	b := NewBucket(60, 2)
+	start := b.last
+	if !b.idle(start) {
+		t.Error("a new bucket should be idle")
+	}
+	b.allow(start)
+	if b.idle(start.Add(500 * time.Millisecond)) {
+		t.Error("bucket should not be idle while refilling")
+	}
+	if !b.idle(start.Add(time.Second)) {
+		t.Error("bucket should be idle once full")
+	}
+}
+
+func TestBucketWaitPaces(t *testing.T) {
+	// 10 a second: the burst is immediate, then one every 100ms
+	b := NewBucket(600, 2)
+
+	start := time.Now()
+	var waited time.Duration
+	for i := 0; i < 4; i++ {
+		w, err := b.Wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		waited += w
+	}
+	elapsed := time.Since(start)
+
+	if elapsed < 180*time.Millisecond {
+		t.Errorf("4 requests with a burst of 2 at 10/s took %v, want at least 200ms", elapsed)
+	}
+	if waited < 180*time.Millisecond || waited > elapsed {
+		t.Errorf("Wait reported %v waiting in %v", waited, elapsed)
+	}
+}
+
+func TestBucketWaitCancelled(t *testing.T) {
+	b := NewBucket(1, 1)
+	if _, err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled Wait took %v", elapsed)
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often a Limiter drops the buckets of clients that
+// have gone quiet
+const sweepInterval = time.Minute
+
+// Limiter keeps a bucket per client key, such as a user ID or an IP
+// address. A nil *Limiter allows everything.
+type Limiter struct {
+	perMinute, burst int
+
+	mu      sync.Mutex
+	buckets map[string]*Bucket
+	swept   time.Time
+}
+
+// Decision is the outcome of Limiter.Allow, with what a client needs to
+// pace itself
+type Decision struct {
+	Allowed bool
+	// Limit is the most requests a client can make at once
+	Limit int
+	// Remaining is how many requests the client can make right now
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, when this
+	// one wasn't
+	RetryAfter time.Duration
+	// Reset is how long until the client's full limit is available again
+	Reset time.Duration
+}
+
+// NewLimiter creates a limiter giving each key perMinute requests a minute
+// with bursts of up to burst. It returns nil, no limit, when perMinute is
+// not positive.
+func NewLimiter(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		perMinute: perMinute,
+		burst:     burst,
+		buckets:   make(map[string]*Bucket),
+		swept:     time.Now(),
+	}
+}
+
+// Allow spends one of key's requests if it has any left
+func (l *Limiter) Allow(key string) Decision {
+	if l == nil {
+		return Decision{Allowed: true}
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	if now.Sub(l.swept) >= sweepInterval {
+		for k, b := range l.buckets {
+			if b.idle(now) {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.perMinute, l.burst)
+		l.buckets[key] = b
+	}
+	l.mu.Unlock()
+
+	allowed, remaining, next, full := b.allow(now)
+	return Decision{
+		Allowed:    allowed,
+		Limit:      l.burst,
+		Remaining:  remaining,
+		RetryAfter: next,
+		Reset:      full,
+	}
+}
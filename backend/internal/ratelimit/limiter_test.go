@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name             string
+		perMinute, burst int
+		keys             []string
+		allowed          []bool
+	}{
+		{
+			name:      "burst per key",
+			perMinute: 1, burst: 2,
+			keys:    []string{"a", "a", "a"},
+			allowed: []bool{true, true, false},
+		},
+		{
+			name:      "keys are independent",
+			perMinute: 1, burst: 1,
+			keys:    []string{"a", "b", "a", "b", "c"},
+			allowed: []bool{true, true, false, false, true},
+		},
+		{
+			name:      "burst below 1",
+			perMinute: 1, burst: 0,
+			keys:    []string{"a", "a"},
+			allowed: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.perMinute, tt.burst)
+			for i, key := range tt.keys {
+				if d := l.Allow(key); d.Allowed != tt.allowed[i] {
+					t.Errorf("request %d for %s: Allowed = %v, want %v", i+1, key, d.Allowed, tt.allowed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterDecision(t *testing.T) {
+	// One request a second, up to 2 at once
+	l := NewLimiter(60, 2)
+
+	d := l.Allow("a")
+	if !d.Allowed || d.Limit != 2 || d.Remaining != 1 || d.RetryAfter != 0 {
+		t.Errorf("first decision = %+v, want allowed with 1 of 2 remaining", d)
+	}
+	if d.Reset <= 0 || d.Reset > time.Second {
+		t.Errorf("first Reset = %v, want up to 1s", d.Reset)
+	}
+
+	l.Allow("a")
+	d = l.Allow("a")
+	if d.Allowed || d.Remaining != 0 {
+		t.Errorf("third decision = %+v, want denied with none remaining", d)
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %v, want up to 1s", d.RetryAfter)
+	}
+	if d.Reset <= time.Second || d.Reset > 2*time.Second {
+		t.Errorf("Reset = %v, want between 1s and 2s", d.Reset)
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	l := NewLimiter(60, 1)
+	l.Allow("quiet")
+	l.Allow("busy")
+
+	// Pretend a sweep is due, with quiet's bucket refilled and busy's not
+	l.swept = l.swept.Add(-sweepInterval)
+	l.buckets["quiet"].last = l.buckets["quiet"].last.Add(-time.Minute)
+	l.Allow("busy")
+
+	if _, ok := l.buckets["quiet"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("busy bucket was swept")
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	if l := NewLimiter(0, 10); l != nil {
+		t.Fatal("NewLimiter(0, 10) should be nil, no limit")
+	}
+	var l *Limiter
+	if d := l.Allow("a"); !d.Allowed {
+		t.Error("a nil limiter should allow everything")
+	}
+}
@@ -0,0 +1,238 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milindkumar1/swishradar/internal/metrics"
+)
+
+var limiterWait = metrics.Default.NewCounterVec("swishradar_upstream_limiter_wait_seconds_total",
+	"Time requests spent waiting for the per-host rate limiter.", "host")
+
+var (
+	hostsMu sync.RWMutex
+	hosts   = make(map[string]*Bucket)
+)
+
+// SetHostLimit paces every request sent through a Transport to host at
+// perMinute a minute, with bursts of up to burst. Every client shares the
+// host's budget. A perMinute of 0 removes the limit.
+func SetHostLimit(host string, perMinute, burst int) {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	if b := NewBucket(perMinute, burst); b != nil {
+		hosts[host] = b
+	} else {
+		delete(hosts, host)
+	}
+}
+
+func hostBucket(host string) *Bucket {
+	hostsMu.RLock()
+	defer hostsMu.RUnlock()
+	return hosts[host]
+}
+
+// maxRetryAfter caps how long a Retry-After header can hold a request, so
+// a long throttle fails the request rather than hanging it
+const maxRetryAfter = 30 * time.Second
+
+// Transport paces requests with the destination host's limit, retries GETs
+// that come back 429 or 5xx with jittered exponential backoff, and lets
+// concurrent identical GETs share one upstream call. Each attempt waits for
+// the limiter, so retries spend the same budget as first tries.
+//
+// A shared call runs on a context of its own, detached from every caller
+// and bounded by SharedTimeout, so the caller that started it can go away
+// without failing the others. Each caller stops waiting when its own
+// context is done.
+type Transport struct {
+	// Retries is how many extra attempts a throttled or failed GET gets
+	// (default 3)
+	Retries int
+	// Backoff is the base delay between attempts, doubled each time and
+	// jittered (default 500ms). A Retry-After header takes precedence.
+	Backoff time.Duration
+	// SharedTimeout bounds a shared GET, retries included, in place of
+	// its callers' deadlines (default 2m)
+	SharedTimeout time.Duration
+	// Next sends the requests (default http.DefaultTransport)
+	Next http.RoundTripper
+
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+// call is a GET in flight that other identical requests wait on
+type call struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+		return t.send(req)
+	}
+
+	key := requestKey(req)
+	t.mu.Lock()
+	if t.inflight == nil {
+		t.inflight = make(map[string]*call)
+	}
+	c, ok := t.inflight[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		t.inflight[key] = c
+		go t.share(key, c, req)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.response(req)
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// share makes the upstream call for c on a detached context, keeping the
+// values of req's, then hands the result to everyone waiting on it
+func (t *Transport) share(key string, c *call, req *http.Request) {
+	timeout := t.SharedTimeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), timeout)
+	defer cancel()
+
+	c.resp, c.err = t.send(req.WithContext(ctx))
+	if c.err == nil {
+		c.body, c.err = io.ReadAll(c.resp.Body)
+		c.resp.Body.Close()
+	}
+
+	t.mu.Lock()
+	delete(t.inflight, key)
+	t.mu.Unlock()
+	close(c.done)
+}
+
+// response gives req its own copy of the shared response
+func (c *call) response(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	resp := *c.resp
+	resp.Header = c.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(c.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+// send makes the request, waiting for the host's limiter before each
+// attempt and retrying GETs on 429 and 5xx
+func (t *Transport) send(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	retries := t.Retries
+	if retries == 0 {
+		retries = 3
+	}
+	backoff := t.Backoff
+	if backoff == 0 {
+		backoff = 500 * time.Millisecond
+	}
+	if req.Method != http.MethodGet {
+		retries = 0
+	}
+
+	bucket := hostBucket(req.URL.Host)
+	for attempt := 0; ; attempt++ {
+		waited, err := bucket.Wait(req.Context())
+		if waited > 0 {
+			limiterWait.Add(waited.Seconds(), req.URL.Host)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil || !retryable(resp.StatusCode) || attempt == retries {
+			return resp, err
+		}
+
+		delay := retryAfter(resp)
+		if delay == 0 {
+			delay = jitter(backoff << attempt)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryAfter reads a Retry-After header given in seconds, capped at
+// maxRetryAfter, or returns 0 when there is none
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	if d := time.Duration(secs) * time.Second; d < maxRetryAfter {
+		return d
+	}
+	return maxRetryAfter
+}
+
+// jitter returns d plus up to 50%
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requestKey identifies identical requests: the same URL with the same
+// headers, which include the cookies, so calls made with different
+// credentials are never shared
+func requestKey(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,335 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportCoalescesIdenticalGETs(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		cookies  []string
+		wantHits int32
+	}{
+		{"identical", http.MethodGet, []string{"s2=a", "s2=a", "s2=a", "s2=a"}, 1},
+		{"different credentials", http.MethodGet, []string{"s2=a", "s2=b"}, 2},
+		{"not GET", http.MethodPost, []string{"s2=a", "s2=a"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			release := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				<-release
+				w.Header().Set("X-Hit", "1")
+				io.WriteString(w, "league "+r.Header.Get("Cookie"))
+			}))
+			defer srv.Close()
+			client := &http.Client{Transport: &Transport{}}
+
+			var wg sync.WaitGroup
+			bodies := make([]string, len(tt.cookies))
+			for i, cookie := range tt.cookies {
+				wg.Add(1)
+				go func(i int, cookie string) {
+					defer wg.Done()
+					req, _ := http.NewRequest(tt.method, srv.URL+"/league", nil)
+					req.Header.Set("Cookie", cookie)
+					resp, err := client.Do(req)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer resp.Body.Close()
+					body, _ := io.ReadAll(resp.Body)
+					bodies[i] = string(body)
+					if resp.Header.Get("X-Hit") != "1" {
+						t.Error("shared response is missing its headers")
+					}
+				}(i, cookie)
+			}
+
+			// Give every request time to start before the first one returns
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("upstream hit %d times, want %d", got, tt.wantHits)
+			}
+			for i, cookie := range tt.cookies {
+				if want := "league " + cookie; bodies[i] != want {
+					t.Errorf("request %d got %q, want %q", i, bodies[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportSharedCallOutlivesFirstCaller(t *testing.T) {
+	var hits atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		io.WriteString(w, "league")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	// The first caller starts the shared call, then gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/league", nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		first <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/league", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			second <- ""
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		second <- string(body)
+	}()
+	// Give the second request time to join the shared call
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-first:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled caller got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled caller is still waiting on the shared call")
+	}
+
+	close(release)
+	if body := <-second; body != "league" {
+		t.Errorf("second caller got %q, want the shared response", body)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("upstream hit %d times, want 1", got)
+	}
+}
+
+func TestTransportSharedTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tr := &Transport{SharedTimeout: 50 * time.Millisecond}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shared call ran for %v past its 50ms timeout", elapsed)
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statuses   []int // answered in order, then 200
+		retries    int
+		wantStatus int
+		wantHits   int32
+	}{
+		{"success", http.MethodGet, nil, 3, http.StatusOK, 1},
+		{"throttled then ok", http.MethodGet, []int{429, 429}, 3, http.StatusOK, 3},
+		{"server error then ok", http.MethodGet, []int{500, 503}, 3, http.StatusOK, 3},
+		{"gives up", http.MethodGet, []int{503, 503, 503}, 2, http.StatusServiceUnavailable, 3},
+		{"client error", http.MethodGet, []int{404}, 3, http.StatusNotFound, 1},
+		{"POST is not retried", http.MethodPost, []int{503}, 3, http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(hits.Add(1))
+				if n <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[n-1])
+					return
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer srv.Close()
+
+			tr := &Transport{Retries: tt.retries, Backoff: time.Millisecond}
+			req, _ := http.NewRequest(tt.method, srv.URL, nil)
+			resp, err := tr.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("upstream hit %d times, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	tr := &Transport{Backoff: time.Millisecond}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s Retry-After", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2026 07:28:00 GMT", 0},
+		{"2", 2 * time.Second},
+		{"30", maxRetryAfter},
+		{"3600", maxRetryAfter},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(resp); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Millisecond, time.Second} {
+		for i := 0; i < 100; i++ {
+			if got := jitter(d); got < d || got > d+d/2 {
+				t.Fatalf("jitter(%v) = %v, want between %v and %v", d, got, d, d+d/2)
+			}
+		}
+	}
+}
+
+func TestTransportPacesHost(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	// 10 a second after a burst of 1
+	SetHostLimit(host, 600, 1)
+	defer SetHostLimit(host, 0, 0)
+
+	tr := &Transport{}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		// Distinct URLs so nothing is coalesced
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/?i="+strconv.Itoa(i), nil)
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("3 requests at 10/s with a burst of 1 took %v, want at least 200ms", elapsed)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("upstream hit %d times, want 3", got)
+	}
+
+	SetHostLimit(host, 0, 0)
+	if b := hostBucket(host); b != nil {
+		t.Error("SetHostLimit(host, 0, 0) should remove the limit")
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	req := func(rawURL string, header http.Header) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, rawURL, nil)
+		r.Header = header
+		return r
+	}
+
+	a := requestKey(req("https://espn.test/league?view=mTeam", http.Header{"Cookie": {"s2=a"}, "Accept": {"json"}}))
+	tests := []struct {
+		name string
+		req  *http.Request
+		same bool
+	}{
+		{"same headers in another order", req("https://espn.test/league?view=mTeam", http.Header{"Accept": {"json"}, "Cookie": {"s2=a"}}), true},
+		{"other cookie", req("https://espn.test/league?view=mTeam", http.Header{"Cookie": {"s2=b"}, "Accept": {"json"}}), false},
+		{"other view", req("https://espn.test/league?view=mRoster", http.Header{"Cookie": {"s2=a"}, "Accept": {"json"}}), false},
+		{"no headers", req("https://espn.test/league?view=mTeam", http.Header{}), false},
+	}
+	for _, tt := range tests {
+		if got := requestKey(tt.req) == a; got != tt.same {
+			t.Errorf("%s: same key = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+}
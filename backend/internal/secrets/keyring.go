@@ -0,0 +1,228 @@
+// Package secrets encrypts small values such as ESPN cookies before they are
+// stored
+package secrets
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// prefix marks a value sealed by a Keyring. Values without it are treated as
+// legacy plaintext.
+const prefix = "enc:v1:"
+
+// dataKeySize is the length of the per-value AES-256 data key
+const dataKeySize = 32
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ErrUnknownKey is returned when a value was sealed with a key the keyring
+// does not hold
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring encrypts values with envelope encryption: each value gets its own
+// random data key, which is itself sealed with the primary key encryption
+// key. Older keys are kept so values sealed with them can still be opened
+// after a rotation.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// Key is a named AES-256 key encryption key
+type Key struct {
+	ID  string
+	Key []byte
+}
+
+// NewKeyring creates a keyring that seals with the first key and opens with
+// any of them
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+
+	k := &Keyring{primary: keys[0].ID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("invalid key id %q: use letters, digits, _ or -", key.ID)
+		}
+		if len(key.Key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes, got %d", key.ID, len(key.Key))
+		}
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		aead, err := newGCM(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.ID, err)
+		}
+		k.keys[key.ID] = aead
+	}
+	return k, nil
+}
+
+// ParseKeyring reads keys written as id:base64key, separated by commas or
+// newlines. Blank lines and lines starting with # are ignored. The first key
+// is the primary.
+func ParseKeyring(text string) (*Keyring, error) {
+	var keys []Key
+	scanner := bufio.NewScanner(strings.NewReader(strings.ReplaceAll(text, ",", "\n")))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q: expected id:base64key", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not valid base64: %w", id, err)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Key: key})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewKeyring(keys...)
+}
+
+// LoadKeyring builds a keyring from keys (CREDENTIAL_KEYS), or from the file
+// at path (CREDENTIAL_KEY_FILE). It returns nil when neither is set.
+func LoadKeyring(keys, path string) (*Keyring, error) {
+	if keys != "" {
+		return ParseKeyring(keys)
+	}
+	if path != "" {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential key file: %w", err)
+		}
+		return ParseKeyring(string(text))
+	}
+	return nil, nil
+}
+
+// PrimaryKeyID returns the ID of the key new values are sealed with
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt seals plaintext with a fresh data key. aad binds the value to its
+// context, such as the column it is stored in, and must be passed again to
+// Decrypt.
+func (k *Keyring) Encrypt(plaintext, aad string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(dataAEAD, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + k.primary + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values that were never encrypted
+// are returned unchanged so existing rows keep working until they are
+// re-encrypted.
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	keyID := parts[0]
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed data key: %w", err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, sealed, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value is plaintext or sealed with a key other
+// than the primary
+func (k *Keyring) NeedsRotation(value string) bool {
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return keyID != k.primary
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, which is prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
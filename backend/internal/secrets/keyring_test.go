@@ -0,0 +1,202 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(id string, fill byte) Key {
+	return Key{ID: id, Key: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func mustKeyring(t *testing.T, keys ...Key) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	k := mustKeyring(t, testKey("k1", 1))
+
+	for _, plaintext := range []string{"", "{ABC-123}", "AEBx%2Fv3lQ%3D%3D", strings.Repeat("s2", 500)} {
+		sealed, err := k.Encrypt(plaintext, "users.espn_s2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(sealed) {
+			t.Errorf("Encrypt(%q) = %q, missing prefix", plaintext, sealed)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Errorf("Encrypt(%q) leaks the plaintext", plaintext)
+		}
+		got, err := k.Decrypt(sealed, "users.espn_s2")
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if got != plaintext {
+			t.Errorf("Decrypt = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestEncryptUsesFreshDataKey(t *testing.T) {
+	k := mustKeyring(t, testKey("k1", 1))
+
+	a, err := k.Encrypt("cookie", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := k.Encrypt("cookie", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptAfterRotation(t *testing.T) {
+	old := mustKeyring(t, testKey("k1", 1))
+	sealed, err := old.Encrypt("cookie", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := mustKeyring(t, testKey("k2", 2), testKey("k1", 1))
+	if got, err := rotated.Decrypt(sealed, "aad"); err != nil || got != "cookie" {
+		t.Errorf("Decrypt with rotated keyring = %q, %v; want cookie", got, err)
+	}
+
+	dropped := mustKeyring(t, testKey("k2", 2))
+	if _, err := dropped.Decrypt(sealed, "aad"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt without the old key: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestNeedsRotation(t *testing.T) {
+	k1 := mustKeyring(t, testKey("k1", 1))
+	sealedK1, err := k1.Encrypt("cookie", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2 := mustKeyring(t, testKey("k2", 2), testKey("k1", 1))
+	sealedK2, err := k2.Encrypt("cookie", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"plaintext", "cookie", true},
+		{"empty", "", true},
+		{"old key", sealedK1, true},
+		{"primary key", sealedK2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := k2.NeedsRotation(tt.value); got != tt.want {
+				t.Errorf("NeedsRotation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	k := mustKeyring(t, testKey("k1", 1))
+	sealed, err := k.Encrypt("cookie", "users.espn_s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix), ":")
+
+	flip := func(encoded string) string {
+		b, err := base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b[len(b)-1] ^= 1
+		return base64.RawStdEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		aad   string
+	}{
+		{"aad mismatch", sealed, "users.espn_swid"},
+		{"missing aad", sealed, ""},
+		{"tampered data key", prefix + parts[0] + ":" + flip(parts[1]) + ":" + parts[2], "users.espn_s2"},
+		{"tampered ciphertext", prefix + parts[0] + ":" + parts[1] + ":" + flip(parts[2]), "users.espn_s2"},
+		{"malformed", prefix + "k1:abc", "users.espn_s2"},
+		{"bad base64", prefix + "k1:!!:!!", "users.espn_s2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := k.Decrypt(tt.value, tt.aad); err == nil {
+				t.Errorf("Decrypt = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestDecryptLegacyPlaintext(t *testing.T) {
+	k := mustKeyring(t, testKey("k1", 1))
+
+	for _, value := range []string{"", "{ABC-123}", "AEBx%2Fv3lQ%3D%3D"} {
+		got, err := k.Decrypt(value, "users.espn_s2")
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", value, err)
+		}
+		if got != value {
+			t.Errorf("Decrypt(%q) = %q, want it unchanged", value, got)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	other := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	tests := []struct {
+		name    string
+		text    string
+		primary string
+		wantErr bool
+	}{
+		{"single", "k1:" + key, "k1", false},
+		{"comma separated", "k2:" + other + ",k1:" + key, "k2", false},
+		{"file with comments", "# active\nk2: " + other + "\n\n# old\nk1:" + key + "\n", "k2", false},
+		{"empty", "", "", true},
+		{"missing id", key, "", true},
+		{"bad base64", "k1:not base64", "", true},
+		{"short key", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "", true},
+		{"bad id", "k 1:" + key, "", true},
+		{"duplicate id", "k1:" + key + ",k1:" + other, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseKeyring(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ParseKeyring succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := k.PrimaryKeyID(); got != tt.primary {
+				t.Errorf("PrimaryKeyID = %q, want %q", got, tt.primary)
+			}
+		})
+	}
+}
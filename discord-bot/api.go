@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// apiClient is shared by all calls to the SwishRadar API
+var apiClient = &http.Client{Timeout: 20 * time.Second}
+
+// leaguePath returns the API path scoped to LEAGUE_ID, or legacy when no
+// league is configured and the API's default league should be used
+func leaguePath(scoped, legacy string) string {
+	if leagueID == "" {
+		return legacy
+	}
+	return "/api/v1/leagues/" + leagueID + scoped
+}
+
+// apiGet fetches path from the API and decodes the JSON response into v
+func apiGet(path string, v interface{}) error {
+	return apiDo(http.MethodGet, path, nil, v)
+}
+
+// apiPost sends body as JSON to path and decodes the JSON response into v
+func apiPost(path string, body interface{}, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return apiDo(http.MethodPost, path, bytes.NewReader(payload), v)
+}
+
+// apiDo sends an authenticated request to the API
+func apiDo(method, path string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequest(method, apiURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+	}
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	return decodeAPIResponse(resp, v)
+}
+
+// decodeAPIResponse turns non-2xx responses into errors, using the API's
+// {"error": "..."} body when present
+func decodeAPIResponse(resp *http.Response, v interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s", apiErr.Error)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commands is the bot's complete slash command set. On startup the
+// registered commands are made to match it exactly: new commands are added,
+// changed ones updated and anything not listed here is removed.
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "matchup",
+		Description: "Get current week's matchup prediction",
+	},
+	{
+		Name:        "streaming",
+		Description: "Get top waiver wire streaming recommendations",
+	},
+	{
+		Name:        "powerrankings",
+		Description: "Get current power rankings for your league",
+	},
+	{
+		Name:        "player",
+		Description: "Get player statistics and trends",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Player name",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "trade",
+		Description: "Compare category impact of a trade for both teams",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "give",
+				Description:  "Players you send, comma separated",
+				Required:     true,
+				Autocomplete: true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "get",
+				Description:  "Players you receive, comma separated",
+				Required:     true,
+				Autocomplete: true,
+			},
+		},
+	},
+}
+
+// registerCommands syncs commands with Discord, registering them to guildID
+// or globally when it is empty. Guild commands update instantly, so
+// development uses one. Nothing is sent when the registered set already
+// matches. Commands no longer declared are deleted from the synced scope,
+// and from the global one too for a guild when pruneGlobal is set.
+func registerCommands(s *discordgo.Session, guildID string, pruneGlobal bool) error {
+	appID := s.State.User.ID
+
+	scope := "globally"
+	if guildID != "" {
+		scope = "in guild " + guildID
+	}
+
+	existing, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to list registered commands: %w", err)
+	}
+
+	added, updated, removed, err := diffCommands(existing, commands)
+	if err != nil {
+		return err
+	}
+	if len(added)+len(updated)+len(removed) == 0 {
+		log.Printf("Slash commands up to date (%d registered %s)", len(commands), scope)
+	} else {
+		// Bulk overwrite replaces the whole set, which also deletes stale commands
+		if _, err := s.ApplicationCommandBulkOverwrite(appID, guildID, commands); err != nil {
+			return fmt.Errorf("failed to overwrite commands %s: %w", scope, err)
+		}
+		log.Printf("Synced slash commands %s: added %v, updated %v, removed %v", scope, added, updated, removed)
+	}
+
+	if guildID != "" && pruneGlobal {
+		return removeStaleGlobalCommands(s, appID)
+	}
+	return nil
+}
+
+// removeStaleGlobalCommands deletes global commands that are no longer
+// declared, such as those older versions registered, so they don't show up
+// next to the guild's. Development and production share the application,
+// so this deletes production's commands that a branch dropped or renamed;
+// it only runs when PRUNE_GLOBAL_COMMANDS opts in. Declared ones are left
+// alone.
+func removeStaleGlobalCommands(s *discordgo.Session, appID string) error {
+	global, err := s.ApplicationCommands(appID, "")
+	if err != nil {
+		return fmt.Errorf("failed to list global commands: %w", err)
+	}
+
+	declared := make(map[string]bool, len(commands))
+	for _, cmd := range commands {
+		declared[cmd.Name] = true
+	}
+	var kept int
+	for _, cmd := range global {
+		if declared[cmd.Name] {
+			kept++
+			continue
+		}
+		if err := s.ApplicationCommandDelete(appID, "", cmd.ID); err != nil {
+			return fmt.Errorf("failed to delete stale global command %s: %w", cmd.Name, err)
+		}
+		log.Printf("Deleted stale global command %s", cmd.Name)
+	}
+	if kept > 0 {
+		log.Printf("Warning: %d declared commands are also registered globally and will appear twice in the guild", kept)
+	}
+	return nil
+}
+
+// diffCommands compares registered commands with the desired set by name
+func diffCommands(existing, desired []*discordgo.ApplicationCommand) (added, updated, removed []string, err error) {
+	registered := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, cmd := range existing {
+		registered[cmd.Name] = cmd
+	}
+
+	for _, cmd := range desired {
+		current, ok := registered[cmd.Name]
+		if !ok {
+			added = append(added, cmd.Name)
+			continue
+		}
+		delete(registered, cmd.Name)
+
+		same, err := commandsEqual(current, cmd)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !same {
+			updated = append(updated, cmd.Name)
+		}
+	}
+
+	for name := range registered {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+
+	return added, updated, removed, nil
+}
+
+// commandSpec holds the parts of a command that we declare, so server-set
+// fields like IDs and versions don't register as changes
+type commandSpec struct {
+	Type        discordgo.ApplicationCommandType `json:"type"`
+	Name        string                           `json:"name"`
+	Description string                           `json:"description"`
+	Options     []optionSpec                     `json:"options"`
+}
+
+type optionSpec struct {
+	Type         discordgo.ApplicationCommandOptionType `json:"type"`
+	Name         string                                 `json:"name"`
+	Description  string                                 `json:"description"`
+	Required     bool                                   `json:"required"`
+	Autocomplete bool                                   `json:"autocomplete"`
+	Choices      []string                               `json:"choices"`
+	Options      []optionSpec                           `json:"options"`
+}
+
+func commandsEqual(a, b *discordgo.ApplicationCommand) (bool, error) {
+	specA, err := json.Marshal(specOf(a))
+	if err != nil {
+		return false, fmt.Errorf("failed to compare command %s: %w", a.Name, err)
+	}
+	specB, err := json.Marshal(specOf(b))
+	if err != nil {
+		return false, fmt.Errorf("failed to compare command %s: %w", b.Name, err)
+	}
+	return string(specA) == string(specB), nil
+}
+
+func specOf(cmd *discordgo.ApplicationCommand) commandSpec {
+	spec := commandSpec{
+		Type:        cmd.Type,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Options:     optionSpecs(cmd.Options),
+	}
+	// Discord reports the default type explicitly
+	if spec.Type == 0 {
+		spec.Type = discordgo.ChatApplicationCommand
+	}
+	return spec
+}
+
+func optionSpecs(options []*discordgo.ApplicationCommandOption) []optionSpec {
+	if len(options) == 0 {
+		return nil
+	}
+	specs := make([]optionSpec, 0, len(options))
+	for _, opt := range options {
+		spec := optionSpec{
+			Type:         opt.Type,
+			Name:         opt.Name,
+			Description:  opt.Description,
+			Required:     opt.Required,
+			Autocomplete: opt.Autocomplete,
+			Options:      optionSpecs(opt.Options),
+		}
+		for _, choice := range opt.Choices {
+			spec.Choices = append(spec.Choices, fmt.Sprintf("%s=%v", choice.Name, choice.Value))
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
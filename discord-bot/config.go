@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// botConfig is every setting the bot reads, loaded with config.Load from
+// the environment, .env and CONFIG_FILE
+type botConfig struct {
+	DiscordToken string `env:"DISCORD_TOKEN" required:"true" secret:"true"`
+
+	// Env is "production" to register slash commands globally, as the bot
+	// always has; anything else registers them to DiscordGuildID, where
+	// updates show up instantly
+	Env            string `env:"ENV" default:"production"`
+	DiscordGuildID string `env:"DISCORD_GUILD_ID"`
+	// PruneGlobalCommands lets a guild registration delete global commands
+	// this build doesn't declare, including production's
+	PruneGlobalCommands bool `env:"PRUNE_GLOBAL_COMMANDS"`
+
+	APIURL   string `env:"API_URL" default:"http://localhost:8080"`
+	APIToken string `env:"API_TOKEN" secret:"true"`
+
+	LeagueID        string `env:"LEAGUE_ID"`
+	LeagueChannelID string `env:"LEAGUE_CHANNEL_ID"`
+
+	DailySchedule       string `env:"CRON_SCHEDULE" default:"0 9 * * *"`
+	RecapSchedule       string `env:"RECAP_SCHEDULE" default:"0 * * * *"`
+	TransactionSchedule string `env:"TRANSACTION_SCHEDULE" default:"*/5 * * * *"`
+
+	HealthAddr      string        `env:"HEALTH_ADDR" default:":8090"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s"`
+}
+
+// Validate checks the settings the bot can't start with
+func (c *botConfig) Validate() error {
+	var errs []error
+	if c.Env != "production" && c.DiscordGuildID == "" {
+		errs = append(errs, errors.New("DISCORD_GUILD_ID is required outside production (unset ENV to register commands globally)"))
+	}
+	if u, err := url.Parse(c.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("API_URL must be an absolute URL, got %q", c.APIURL))
+	}
+	for _, s := range []struct{ key, spec string }{
+		{"CRON_SCHEDULE", c.DailySchedule},
+		{"RECAP_SCHEDULE", c.RecapSchedule},
+		{"TRANSACTION_SCHEDULE", c.TransactionSchedule},
+	} {
+		if _, err := cron.ParseStandard(s.spec); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", s.key, err))
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SHUTDOWN_TIMEOUT must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// commandGuildID is the guild slash commands are registered to, or "" to
+// register them globally
+func (c *botConfig) commandGuildID() string {
+	if c.Env == "production" {
+		return ""
+	}
+	return c.DiscordGuildID
+}
@@ -4,6 +4,17 @@ go 1.21
 
 require (
 	github.com/bwmarrin/discordgo v0.27.1
-	github.com/joho/godotenv v1.5.1
+	github.com/milindkumar1/swishradar v0.0.0
 	github.com/robfig/cron/v3 v3.0.1
 )
+
+require (
+	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/joho/godotenv v1.5.1 // indirect
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The shared config package lives in the backend module
+replace github.com/milindkumar1/swishradar => ../backend
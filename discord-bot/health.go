@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// botMetrics counts bot activity for /metrics
+type botMetrics struct {
+	mu       sync.Mutex
+	commands map[string]int64
+	errors   map[string]int64
+	started  time.Time
+
+	gatewayConnected atomic.Bool
+	inFlight         atomic.Int64
+}
+
+var metrics = &botMetrics{
+	commands: make(map[string]int64),
+	errors:   make(map[string]int64),
+	started:  time.Now(),
+}
+
+func (m *botMetrics) recordCommand(name string) {
+	m.mu.Lock()
+	m.commands[name]++
+	m.mu.Unlock()
+}
+
+func (m *botMetrics) recordError(source string) {
+	m.mu.Lock()
+	m.errors[source]++
+	m.mu.Unlock()
+}
+
+// logError logs a handler or job failure and counts it against source
+// (a command name or job name)
+func logError(source, format string, args ...interface{}) {
+	metrics.recordError(source)
+	log.Printf(format, args...)
+}
+
+// handlerTracker lets shutdown wait for in-flight handlers. Once draining
+// starts no new handlers are admitted.
+type handlerTracker struct {
+	mu       sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+var handlers handlerTracker
+
+// begin admits a handler, returning false once shutdown has started
+func (t *handlerTracker) begin() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return false
+	}
+	t.wg.Add(1)
+	metrics.inFlight.Add(1)
+	return true
+}
+
+func (t *handlerTracker) done() {
+	metrics.inFlight.Add(-1)
+	t.wg.Done()
+}
+
+// drain stops admitting handlers and waits for running ones until ctx ends
+func (t *handlerTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d handlers still running: %w", metrics.inFlight.Load(), ctx.Err())
+	}
+}
+
+// trackGateway keeps metrics.gatewayConnected in sync with the session
+func trackGateway(dg *discordgo.Session) {
+	dg.AddHandler(func(s *discordgo.Session, _ *discordgo.Connect) {
+		metrics.gatewayConnected.Store(true)
+	})
+	dg.AddHandler(func(s *discordgo.Session, _ *discordgo.Disconnect) {
+		metrics.gatewayConnected.Store(false)
+	})
+}
+
+// startHealthServer serves /healthz and /metrics for container probes
+func startHealthServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/metrics", handleMetrics)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Health server error: %v", err)
+		}
+	}()
+
+	return srv
+}
+
+// handleHealthz reports 200 while the gateway is connected and the bot is
+// not shutting down, 503 otherwise
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	handlers.mu.Lock()
+	draining := handlers.draining
+	handlers.mu.Unlock()
+
+	connected := metrics.gatewayConnected.Load()
+	status, code := "healthy", http.StatusOK
+	switch {
+	case draining:
+		status, code = "shutting_down", http.StatusServiceUnavailable
+	case !connected:
+		status, code = "disconnected", http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":            status,
+		"gateway_connected": connected,
+		"uptime_seconds":    int(time.Since(metrics.started).Seconds()),
+	})
+}
+
+// handleMetrics writes counters in the Prometheus text format
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+
+	connected := 0
+	if metrics.gatewayConnected.Load() {
+		connected = 1
+	}
+	b.WriteString("# HELP swishradar_bot_gateway_connected Whether the Discord gateway is connected.\n")
+	b.WriteString("# TYPE swishradar_bot_gateway_connected gauge\n")
+	fmt.Fprintf(&b, "swishradar_bot_gateway_connected %d\n", connected)
+
+	b.WriteString("# HELP swishradar_bot_handlers_in_flight Interaction handlers currently running.\n")
+	b.WriteString("# TYPE swishradar_bot_handlers_in_flight gauge\n")
+	fmt.Fprintf(&b, "swishradar_bot_handlers_in_flight %d\n", metrics.inFlight.Load())
+
+	b.WriteString("# HELP swishradar_bot_uptime_seconds Seconds since the bot started.\n")
+	b.WriteString("# TYPE swishradar_bot_uptime_seconds gauge\n")
+	fmt.Fprintf(&b, "swishradar_bot_uptime_seconds %d\n", int(time.Since(metrics.started).Seconds()))
+
+	metrics.mu.Lock()
+	writeCounter(&b, "swishradar_bot_commands_total", "Slash commands and components handled.", "command", metrics.commands)
+	writeCounter(&b, "swishradar_bot_errors_total", "Errors from handlers and scheduled jobs.", "source", metrics.errors)
+	metrics.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+func writeCounter(b *strings.Builder, name, help, label string, values map[string]int64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", name, label, k, values[k])
+	}
+}
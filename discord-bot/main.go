@@ -1,41 +1,56 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/joho/godotenv"
+	"github.com/milindkumar1/swishradar/config"
 	"github.com/robfig/cron/v3"
 )
 
 var (
 	apiURL string
+
+	// apiToken authenticates the bot to the API; it must match the API's
+	// BOT_API_TOKEN
+	apiToken string
+
+	// leagueID and leagueChannelID link the bot to one fantasy league and the
+	// channel it posts scheduled updates to
+	leagueID        string
+	leagueChannelID string
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+	// Settings from the environment, .env and CONFIG_FILE
+	var cfg botConfig
+	if err := config.Load(&cfg); err != nil {
+		log.Fatal(err)
 	}
+	fmt.Println("Configuration:")
+	config.Print(os.Stdout, &cfg)
 
-	token := os.Getenv("DISCORD_TOKEN")
-	if token == "" {
-		log.Fatal("DISCORD_TOKEN environment variable is required")
+	apiURL = cfg.APIURL
+	apiToken = cfg.APIToken
+	if apiToken == "" {
+		log.Println("API_TOKEN not set, API requests will be rejected")
 	}
 
-	apiURL = os.Getenv("API_URL")
-	if apiURL == "" {
-		apiURL = "http://localhost:8080"
+	leagueID = cfg.LeagueID
+	leagueChannelID = cfg.LeagueChannelID
+	if leagueID == "" || leagueChannelID == "" {
+		log.Println("LEAGUE_ID or LEAGUE_CHANNEL_ID not set, scheduled league posts are disabled")
 	}
 
 	// Create Discord session
-	dg, err := discordgo.New("Bot " + token)
+	dg, err := discordgo.New("Bot " + cfg.DiscordToken)
 	if err != nil {
 		log.Fatal("Error creating Discord session:", err)
 	}
@@ -43,6 +58,10 @@ func main() {
 	// Register commands
 	dg.AddHandler(messageCreate)
 	dg.AddHandler(interactionCreate)
+	trackGateway(dg)
+
+	// Liveness and metrics for the container
+	healthServer := startHealthServer(cfg.HealthAddr)
 
 	// Open connection
 	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages
@@ -53,12 +72,16 @@ func main() {
 	}
 
 	// Register slash commands
-	registerCommands(dg)
+	if err := registerCommands(dg, cfg.commandGuildID(), cfg.PruneGlobalCommands); err != nil {
+		dg.Close()
+		log.Fatal("Error registering commands: ", err)
+	}
 
 	// Setup cron jobs for scheduled reports
-	setupCronJobs(dg)
+	scheduler := setupCronJobs(dg, cfg)
 
 	fmt.Println("🏀 SwishRadar Discord Bot is now running!")
+	fmt.Printf("Health checks on %s\n", cfg.HealthAddr)
 	fmt.Println("Press CTRL-C to exit")
 
 	// Wait for interrupt signal
@@ -66,47 +89,46 @@ func main() {
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
 
-	// Cleanup
-	dg.Close()
+	shutdown(dg, scheduler, healthServer, cfg.ShutdownTimeout)
 }
 
-func registerCommands(s *discordgo.Session) {
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "matchup",
-			Description: "Get current week's matchup prediction",
-		},
-		{
-			Name:        "streaming",
-			Description: "Get top waiver wire streaming recommendations",
-		},
-		{
-			Name:        "powerrankings",
-			Description: "Get current power rankings for your league",
-		},
-		{
-			Name:        "player",
-			Description: "Get player statistics and trends",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "name",
-					Description: "Player name",
-					Required:    true,
-				},
-			},
-		},
+// shutdown stops scheduled jobs and waits for running handlers before
+// closing the gateway, all within timeout (SHUTDOWN_TIMEOUT)
+func shutdown(dg *discordgo.Session, scheduler *cron.Cron, healthServer *http.Server, timeout time.Duration) {
+
+	log.Printf("Shutting down (timeout %s)", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Stop scheduling new jobs and wait for running ones
+	select {
+	case <-scheduler.Stop().Done():
+	case <-ctx.Done():
+		log.Println("Timed out waiting for scheduled jobs")
 	}
 
-	for _, cmd := range commands {
-		_, err := s.ApplicationCommandCreate(s.State.User.ID, "", cmd)
-		if err != nil {
-			log.Printf("Cannot create '%v' command: %v", cmd.Name, err)
-		}
+	if err := handlers.drain(ctx); err != nil {
+		log.Printf("Timed out waiting for handlers: %v", err)
+	}
+
+	if err := dg.Close(); err != nil {
+		log.Printf("Error closing Discord session: %v", err)
 	}
+	metrics.gatewayConnected.Store(false)
+
+	if err := healthServer.Shutdown(ctx); err != nil {
+		log.Printf("Error stopping health server: %v", err)
+	}
+
+	log.Println("Shutdown complete")
 }
 
 func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !handlers.begin() {
+		return
+	}
+	defer handlers.done()
+
 	// Ignore bot messages
 	if m.Author.ID == s.State.User.ID {
 		return
@@ -118,7 +140,52 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 }
 
+// interactionName identifies an interaction for metrics: the command name,
+// or the custom ID for components
+func interactionName(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		return i.ApplicationCommandData().Name
+	case discordgo.InteractionMessageComponent:
+		return i.MessageComponentData().CustomID
+	}
+	return "unknown"
+}
+
 func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !handlers.begin() {
+		return
+	}
+	defer handlers.done()
+
+	defer func() {
+		if r := recover(); r != nil {
+			logError(interactionName(i), "Panic handling %s: %v", interactionName(i), r)
+		}
+	}()
+
+	if i.Type != discordgo.InteractionApplicationCommandAutocomplete {
+		metrics.recordCommand(interactionName(i))
+	}
+
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		switch i.ApplicationCommandData().Name {
+		case "trade":
+			handleTradeAutocomplete(s, i)
+		}
+		return
+	}
+	if i.Type == discordgo.InteractionMessageComponent {
+		switch i.MessageComponentData().CustomID {
+		case playerSelectID:
+			handlePlayerSelect(s, i)
+		}
+		return
+	}
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
 	switch i.ApplicationCommandData().Name {
 	case "matchup":
 		handleMatchupCommand(s, i)
@@ -128,6 +195,8 @@ func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		handlePowerRankingsCommand(s, i)
 	case "player":
 		handlePlayerCommand(s, i)
+	case "trade":
+		handleTradeCommand(s, i)
 	}
 }
 
@@ -141,9 +210,11 @@ func handleMatchupCommand(s *discordgo.Session, i *discordgo.InteractionCreate)
 }
 
 func handleStreamingCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Fetch from API
-	resp, err := http.Get(apiURL + "/api/v1/analytics/streaming")
-	if err != nil {
+	// Fetch from API. The endpoint is a placeholder that doesn't return a
+	// list yet, so only a failed request is treated as an error.
+	var recommendations []map[string]interface{}
+	if err := apiGet("/api/v1/analytics/streaming", nil); err != nil {
+		logError(interactionName(i), "Error fetching streaming recommendations: %v", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -152,10 +223,6 @@ func handleStreamingCommand(s *discordgo.Session, i *discordgo.InteractionCreate
 		})
 		return
 	}
-	defer resp.Body.Close()
-
-	var recommendations []map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&recommendations)
 
 	content := "🔥 **Top Waiver Wire Pickups**\n\n"
 	if len(recommendations) == 0 {
@@ -182,27 +249,29 @@ func handlePowerRankingsCommand(s *discordgo.Session, i *discordgo.InteractionCr
 	})
 }
 
-func handlePlayerCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	playerName := options[0].StringValue()
+// setupCronJobs starts the scheduled reports. The schedules were checked
+// when the config loaded.
+func setupCronJobs(s *discordgo.Session, cfg botConfig) *cron.Cron {
+	c := cron.New()
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("📈 **Player Stats: %s**\n\n🔜 Coming soon!", playerName),
-		},
+	// Daily morning report (9 AM by default)
+	c.AddFunc(cfg.DailySchedule, func() {
+		sendDailyReport(s)
 	})
-}
 
-func setupCronJobs(s *discordgo.Session) {
-	c := cron.New()
+	// Matchup recaps go out once a period has closed; checking hourly
+	// catches them soon after ESPN finalizes the scores
+	c.AddFunc(cfg.RecapSchedule, func() {
+		postPendingRecaps(s)
+	})
 
-	// Daily morning report at 9 AM
-	c.AddFunc("0 9 * * *", func() {
-		sendDailyReport(s)
+	// League moves are announced shortly after the API ingests them
+	c.AddFunc(cfg.TransactionSchedule, func() {
+		postPendingTransactions(s)
 	})
 
 	c.Start()
+	return c
 }
 
 func sendDailyReport(s *discordgo.Session) {
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// playerSelectID is the custom ID of the menu offered on ambiguous names
+	playerSelectID = "player_select"
+
+	// confidentMatchScore and confidentMatchGap decide when the top search
+	// result is used directly instead of asking the user to pick
+	confidentMatchScore = 0.95
+	confidentMatchGap   = 0.1
+
+	sparklineGames = 15
+)
+
+// playerSearchResult mirrors an entry of GET /api/v1/players?search=
+type playerSearchResult struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Position string  `json:"position"`
+	Team     string  `json:"team"`
+	Score    float64 `json:"score"`
+}
+
+// playerAverages mirrors the API's per-game averages
+type playerAverages struct {
+	GamesPlayed  int     `json:"games_played"`
+	Points       float64 `json:"points"`
+	Rebounds     float64 `json:"rebounds"`
+	Assists      float64 `json:"assists"`
+	Steals       float64 `json:"steals"`
+	Blocks       float64 `json:"blocks"`
+	Turnovers    float64 `json:"turnovers"`
+	ThreesMade   float64 `json:"threes_made"`
+	FGPct        float64 `json:"fg_pct"`
+	FTPct        float64 `json:"ft_pct"`
+	FantasyValue float64 `json:"fantasy_value"`
+}
+
+// playerDetail mirrors GET /api/v1/players/{id}
+type playerDetail struct {
+	Player struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Position string `json:"position"`
+		Team     string `json:"team"`
+	} `json:"player"`
+	SeasonAverages *playerAverages `json:"season_averages"`
+	Last14Averages *playerAverages `json:"last_14_averages"`
+	InjuryStatus   string          `json:"injury_status"`
+	RosterStatus   string          `json:"roster_status"`
+	FantasyTeam    string          `json:"fantasy_team"`
+	PercentOwned   *float64        `json:"percent_owned"`
+}
+
+func handlePlayerCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	playerName := options[0].StringValue()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error deferring player response: %v", err)
+		return
+	}
+
+	var data struct {
+		Players []playerSearchResult `json:"players"`
+	}
+	if err := apiGet("/api/v1/players?limit=5&search="+url.QueryEscape(playerName), &data); err != nil {
+		logError(interactionName(i), "Error searching players: %v", err)
+		editResponseContent(s, i, fmt.Sprintf("❌ Error searching players: %v", err))
+		return
+	}
+
+	matches := data.Players
+	if len(matches) == 0 {
+		editResponseContent(s, i, fmt.Sprintf("🤷 No player found matching **%s**", playerName))
+		return
+	}
+
+	confident := len(matches) == 1 || matches[0].Score >= confidentMatchScore ||
+		matches[0].Score-matches[1].Score >= confidentMatchGap
+	if confident {
+		sendPlayerCard(s, i, matches[0].ID)
+		return
+	}
+
+	menuOptions := make([]discordgo.SelectMenuOption, 0, len(matches))
+	for _, m := range matches {
+		menuOptions = append(menuOptions, discordgo.SelectMenuOption{
+			Label:       m.Name,
+			Value:       fmt.Sprint(m.ID),
+			Description: fmt.Sprintf("%s · %s", m.Position, m.Team),
+		})
+	}
+
+	content := fmt.Sprintf("🔎 Several players match **%s**. Which one?", playerName)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    playerSelectID,
+				Placeholder: "Choose a player",
+				Options:     menuOptions,
+			},
+		}},
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error sending player choices: %v", err)
+	}
+}
+
+// handlePlayerSelect replaces the choice menu with the chosen player's card
+func handlePlayerSelect(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return
+	}
+
+	var id int
+	if _, err := fmt.Sscan(values[0], &id); err != nil {
+		logError(interactionName(i), "Invalid player selection %q: %v", values[0], err)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error deferring player selection: %v", err)
+		return
+	}
+
+	sendPlayerCard(s, i, id)
+}
+
+// sendPlayerCard edits the interaction response into the player's stat card
+// with a fantasy value sparkline attached
+func sendPlayerCard(s *discordgo.Session, i *discordgo.InteractionCreate, playerID int) {
+	var detail playerDetail
+	if err := apiGet(leaguePath(fmt.Sprintf("/players/%d", playerID), fmt.Sprintf("/api/v1/players/%d", playerID)), &detail); err != nil {
+		logError(interactionName(i), "Error fetching player %d: %v", playerID, err)
+		editResponseContent(s, i, fmt.Sprintf("❌ Error fetching player: %v", err))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📈 " + detail.Player.Name,
+		Description: fmt.Sprintf("%s · %s", detail.Player.Position, detail.Player.Team),
+		Color:       0xf58a1f,
+		Fields: []*discordgo.MessageEmbedField{
+			averagesField("Season", detail.SeasonAverages),
+			averagesField("Last 14 days", detail.Last14Averages),
+			{Name: "Injury", Value: injuryText(detail.InjuryStatus), Inline: true},
+			{Name: "Ownership", Value: ownershipText(detail), Inline: true},
+		},
+	}
+
+	var files []*discordgo.File
+	var stats struct {
+		Stats []struct {
+			FantasyValue float64 `json:"fantasy_value"`
+		} `json:"stats"`
+	}
+	if err := apiGet(fmt.Sprintf("/api/v1/players/%d/stats?games=%d", playerID, sparklineGames), &stats); err != nil {
+		logError(interactionName(i), "Error fetching game log for sparkline: %v", err)
+	} else if len(stats.Stats) > 1 {
+		values := make([]float64, len(stats.Stats))
+		for idx, g := range stats.Stats {
+			values[idx] = g.FantasyValue
+		}
+		png, err := renderSparkline(values, 320, 80)
+		if err != nil {
+			logError(interactionName(i), "Error rendering sparkline: %v", err)
+		} else {
+			files = append(files, &discordgo.File{Name: "sparkline.png", ContentType: "image/png", Reader: bytes.NewReader(png)})
+			embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://sparkline.png"}
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Fantasy value, last %d games", len(values))}
+		}
+	}
+
+	content := ""
+	embeds := []*discordgo.MessageEmbed{embed}
+	components := []discordgo.MessageComponent{}
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+		Files:      files,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error sending player card: %v", err)
+	}
+}
+
+func averagesField(label string, a *playerAverages) *discordgo.MessageEmbedField {
+	if a == nil || a.GamesPlayed == 0 {
+		return &discordgo.MessageEmbedField{Name: label, Value: "No games played"}
+	}
+	return &discordgo.MessageEmbedField{
+		Name: fmt.Sprintf("%s (%d GP)", label, a.GamesPlayed),
+		Value: fmt.Sprintf("`%.1f PTS · %.1f REB · %.1f AST · %.1f STL · %.1f BLK`\n`%.1f 3PM · %.1f TO · %s FG%% · %s FT%% · %.1f FV`",
+			a.Points, a.Rebounds, a.Assists, a.Steals, a.Blocks,
+			a.ThreesMade, a.Turnovers, formatPct(a.FGPct), formatPct(a.FTPct), a.FantasyValue),
+	}
+}
+
+func injuryText(status string) string {
+	switch status {
+	case "":
+		return "Unknown"
+	case "ACTIVE":
+		return "✅ Healthy"
+	case "DAY_TO_DAY":
+		return "⚠️ Day-to-day"
+	case "OUT":
+		return "❌ Out"
+	case "INJURY_RESERVE":
+		return "🚑 Injured reserve"
+	default:
+		words := strings.ToLower(strings.ReplaceAll(status, "_", " "))
+		return "⚠️ " + strings.ToUpper(words[:1]) + words[1:]
+	}
+}
+
+func ownershipText(d playerDetail) string {
+	text := "Free agent"
+	switch {
+	case d.FantasyTeam != "":
+		text = "On " + d.FantasyTeam
+	case d.RosterStatus == "WAIVERS":
+		text = "On waivers"
+	}
+	if d.PercentOwned != nil {
+		text += fmt.Sprintf("\n%.1f%% owned on ESPN", *d.PercentOwned)
+	}
+	return text
+}
+
+// formatPct prints a 0-1 ratio as .xxx
+func formatPct(v float64) string {
+	return strings.TrimPrefix(fmt.Sprintf("%.3f", v), "0")
+}
+
+// editResponseContent replaces a deferred response with plain text
+func editResponseContent(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	components := []discordgo.MessageComponent{}
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error editing interaction response: %v", err)
+	}
+}
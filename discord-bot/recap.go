@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxEmbedsPerMessage is Discord's limit on embeds in a single message
+const maxEmbedsPerMessage = 10
+
+// recapTeam mirrors one side of a matchup recap from the API
+type recapTeam struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	CategoriesWon int    `json:"categories_won"`
+	MVP           *struct {
+		Name         string  `json:"name"`
+		GamesPlayed  int     `json:"games_played"`
+		FantasyValue float64 `json:"fantasy_value"`
+	} `json:"mvp"`
+}
+
+// categoryOutcome mirrors a category result from the API
+type categoryOutcome struct {
+	Category string  `json:"category"`
+	Team1    float64 `json:"team1"`
+	Team2    float64 `json:"team2"`
+	Winner   int     `json:"winner"`
+	Margin   float64 `json:"margin"`
+}
+
+// matchupRecap mirrors a matchup recap from the API
+type matchupRecap struct {
+	MatchupID           int               `json:"matchup_id"`
+	Team1               recapTeam         `json:"team1"`
+	Team2               recapTeam         `json:"team2"`
+	Categories          []categoryOutcome `json:"categories"`
+	ClosestCategory     *categoryOutcome  `json:"closest_category"`
+	WinnerID            int               `json:"winner_id"`
+	PredictedWinnerID   *int              `json:"predicted_winner_id"`
+	PredictionCorrect   *bool             `json:"prediction_correct"`
+	PredictedCategories []categoryOutcome `json:"predicted_categories"`
+	CategoriesCalled    int               `json:"categories_called"`
+}
+
+// weekRecap mirrors GET /api/v1/leagues/{id}/recaps/pending entries
+type weekRecap struct {
+	Season         int            `json:"season"`
+	Week           int            `json:"week"`
+	PeriodStart    *time.Time     `json:"period_start"`
+	PeriodEnd      *time.Time     `json:"period_end"`
+	Matchups       []matchupRecap `json:"matchups"`
+	BiggestBlowout *int           `json:"biggest_blowout_matchup_id"`
+}
+
+// postPendingRecaps posts every finished matchup period that hasn't been
+// recapped yet. The API only marks a week posted once all of its messages
+// were sent, so a failed run is retried on the next tick.
+func postPendingRecaps(s *discordgo.Session) {
+	if leagueID == "" || leagueChannelID == "" {
+		return
+	}
+
+	var data struct {
+		Recaps []weekRecap `json:"recaps"`
+	}
+	if err := apiGet("/api/v1/leagues/"+leagueID+"/recaps/pending", &data); err != nil {
+		logError("recap", "Error fetching pending recaps: %v", err)
+		return
+	}
+
+	for _, recap := range data.Recaps {
+		if err := postWeekRecap(s, recap); err != nil {
+			logError("recap", "Error posting week %d recap: %v", recap.Week, err)
+			return
+		}
+
+		path := fmt.Sprintf("/api/v1/leagues/%s/recaps/%d/%d/posted", leagueID, recap.Season, recap.Week)
+		if err := apiPost(path, nil, nil); err != nil {
+			logError("recap", "Error marking week %d recap posted: %v", recap.Week, err)
+			return
+		}
+		log.Printf("Posted recap for week %d", recap.Week)
+	}
+}
+
+func postWeekRecap(s *discordgo.Session, recap weekRecap) error {
+	header := fmt.Sprintf("📰 **Week %d Recap**", recap.Week)
+	if recap.PeriodStart != nil && recap.PeriodEnd != nil {
+		header += fmt.Sprintf(" (%s – %s)", recap.PeriodStart.Format("Jan 2"), recap.PeriodEnd.Format("Jan 2"))
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(recap.Matchups))
+	for _, m := range recap.Matchups {
+		if recap.BiggestBlowout != nil && *recap.BiggestBlowout == m.MatchupID {
+			header += fmt.Sprintf("\n💥 Biggest blowout: **%s** %d-%d **%s**",
+				m.Team1.Name, m.Team1.CategoriesWon, m.Team2.CategoriesWon, m.Team2.Name)
+		}
+		embeds = append(embeds, matchupRecapEmbed(m))
+	}
+
+	if _, err := s.ChannelMessageSend(leagueChannelID, header); err != nil {
+		return err
+	}
+	for start := 0; start < len(embeds); start += maxEmbedsPerMessage {
+		end := start + maxEmbedsPerMessage
+		if end > len(embeds) {
+			end = len(embeds)
+		}
+		if _, err := s.ChannelMessageSendEmbeds(leagueChannelID, embeds[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchupRecapEmbed(m matchupRecap) *discordgo.MessageEmbed {
+	var table strings.Builder
+	table.WriteString("```\n")
+	fmt.Fprintf(&table, "%-4s %10s %10s\n", "CAT", shortName(m.Team1.Name), shortName(m.Team2.Name))
+	for _, c := range m.Categories {
+		left, right := formatCategoryValue(c.Category, c.Team1), formatCategoryValue(c.Category, c.Team2)
+		switch c.Winner {
+		case m.Team1.ID:
+			left = "*" + left
+		case m.Team2.ID:
+			right = "*" + right
+		}
+		fmt.Fprintf(&table, "%-4s %10s %10s\n", c.Category, left, right)
+	}
+	table.WriteString("```")
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %d - %d %s", m.Team1.Name, m.Team1.CategoriesWon, m.Team2.CategoriesWon, m.Team2.Name),
+		Description: table.String(),
+		Color:       0xf58a1f,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "MVPs", Value: mvpLine(m.Team1) + "\n" + mvpLine(m.Team2)},
+		},
+	}
+
+	if c := m.ClosestCategory; c != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Closest category",
+			Value:  fmt.Sprintf("%s: %s vs %s", c.Category, formatCategoryValue(c.Category, c.Team1), formatCategoryValue(c.Category, c.Team2)),
+			Inline: true,
+		})
+	}
+
+	if m.PredictedWinnerID != nil && m.PredictionCorrect != nil {
+		predicted := m.Team1.Name
+		if *m.PredictedWinnerID == m.Team2.ID {
+			predicted = m.Team2.Name
+		}
+		value := fmt.Sprintf("❌ Predicted %s", predicted)
+		if *m.PredictionCorrect {
+			value = fmt.Sprintf("✅ Called it: %s", predicted)
+		}
+		if len(m.PredictedCategories) > 0 {
+			value += fmt.Sprintf("\n%d/%d categories called", m.CategoriesCalled, len(m.PredictedCategories))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Prediction", Value: value, Inline: true})
+	}
+
+	return embed
+}
+
+func mvpLine(team recapTeam) string {
+	if team.MVP == nil {
+		return fmt.Sprintf("**%s**: n/a", team.Name)
+	}
+	return fmt.Sprintf("**%s**: %s (%.1f FV in %d games)", team.Name, team.MVP.Name, team.MVP.FantasyValue, team.MVP.GamesPlayed)
+}
+
+// shortName trims a team name to fit a table column
+func shortName(name string) string {
+	r := []rune(name)
+	if len(r) > 10 {
+		return string(r[:9]) + "…"
+	}
+	return name
+}
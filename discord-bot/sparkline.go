@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var (
+	sparklineBackground = color.RGBA{R: 0x2b, G: 0x2d, B: 0x31, A: 0xff}
+	sparklineLine       = color.RGBA{R: 0xf5, G: 0x8a, B: 0x1f, A: 0xff}
+	sparklineFill       = color.RGBA{R: 0xf5, G: 0x8a, B: 0x1f, A: 0x40}
+	sparklineAverage    = color.RGBA{R: 0x80, G: 0x84, B: 0x8e, A: 0xff}
+	sparklineLast       = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// renderSparkline draws values (oldest first) as a small line chart PNG with
+// a dotted line at their average. It uses only the standard library so it works
+// without network access or fonts.
+func renderSparkline(values []float64, width, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), sparklineBackground)
+
+	if len(values) > 0 {
+		const pad = 6
+		lo, hi, sum := values[0], values[0], 0.0
+		for _, v := range values {
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+			sum += v
+		}
+		if hi == lo {
+			hi, lo = hi+1, lo-1
+		}
+
+		scaleY := func(v float64) int {
+			return pad + int(float64(height-2*pad)*(hi-v)/(hi-lo))
+		}
+		scaleX := func(i int) int {
+			if len(values) == 1 {
+				return width / 2
+			}
+			return pad + i*(width-2*pad)/(len(values)-1)
+		}
+
+		// Area under the line
+		for i := 0; i+1 < len(values); i++ {
+			x0, x1 := scaleX(i), scaleX(i+1)
+			y0, y1 := scaleY(values[i]), scaleY(values[i+1])
+			for x := x0; x <= x1; x++ {
+				y := y0
+				if x1 != x0 {
+					y = y0 + (y1-y0)*(x-x0)/(x1-x0)
+				}
+				for yy := y; yy < height-pad; yy++ {
+					blend(img, x, yy, sparklineFill)
+				}
+			}
+		}
+
+		// Dotted average
+		avgY := scaleY(sum / float64(len(values)))
+		for x := pad; x < width-pad; x += 4 {
+			img.Set(x, avgY, sparklineAverage)
+			img.Set(x+1, avgY, sparklineAverage)
+		}
+
+		for i := 0; i+1 < len(values); i++ {
+			drawLine(img, scaleX(i), scaleY(values[i]), scaleX(i+1), scaleY(values[i+1]), sparklineLine)
+		}
+
+		last := len(values) - 1
+		fillRect(img, image.Rect(scaleX(last)-2, scaleY(values[last])-2, scaleX(last)+3, scaleY(values[last])+3), sparklineLast)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a 2px line with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		img.SetRGBA(x0+1, y0, c)
+		img.SetRGBA(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// blend alpha-composites c over the existing pixel
+func blend(img *image.RGBA, x, y int, c color.RGBA) {
+	if !(image.Point{X: x, Y: y}).In(img.Bounds()) {
+		return
+	}
+	dst := img.RGBAAt(x, y)
+	a := uint32(c.A)
+	mix := func(s, d uint8) uint8 {
+		return uint8((uint32(s)*a + uint32(d)*(255-a)) / 255)
+	}
+	img.SetRGBA(x, y, color.RGBA{R: mix(c.R, dst.R), G: mix(c.G, dst.G), B: mix(c.B, dst.B), A: 0xff})
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// rosterCacheTTL bounds how stale autocomplete suggestions can be. Discord
+// only gives autocomplete 3 seconds to respond, so we can't fetch every time.
+const rosterCacheTTL = 2 * time.Minute
+
+// rosteredPlayer is a player on one of the league's fantasy teams
+type rosteredPlayer struct {
+	Name     string
+	ProTeam  string
+	TeamName string
+}
+
+var rosterCache struct {
+	sync.Mutex
+	players   []rosteredPlayer
+	fetchedAt time.Time
+}
+
+// leaguePlayers returns every rostered player in the league, cached briefly
+func leaguePlayers() ([]rosteredPlayer, error) {
+	rosterCache.Lock()
+	defer rosterCache.Unlock()
+
+	if rosterCache.players != nil && time.Since(rosterCache.fetchedAt) < rosterCacheTTL {
+		return rosterCache.players, nil
+	}
+
+	var data struct {
+		Teams []struct {
+			Name   string `json:"name"`
+			Roster []struct {
+				Name    string `json:"name"`
+				ProTeam string `json:"proTeam"`
+			} `json:"roster"`
+		} `json:"teams"`
+	}
+	if err := apiGet(leaguePath("/espn/teams", "/api/espn/teams"), &data); err != nil {
+		// Serve stale suggestions rather than none
+		if rosterCache.players != nil {
+			return rosterCache.players, nil
+		}
+		return nil, err
+	}
+
+	players := make([]rosteredPlayer, 0)
+	for _, team := range data.Teams {
+		for _, p := range team.Roster {
+			players = append(players, rosteredPlayer{Name: p.Name, ProTeam: p.ProTeam, TeamName: team.Name})
+		}
+	}
+
+	rosterCache.players = players
+	rosterCache.fetchedAt = time.Now()
+	return players, nil
+}
+
+// splitPlayerList splits a comma-separated player option into trimmed names
+func splitPlayerList(value string) []string {
+	var names []string
+	for _, part := range strings.Split(value, ",") {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// handleTradeAutocomplete suggests rostered players for the name currently
+// being typed. Earlier comma-separated names are kept in the choice value so
+// users can build up a multi-player side.
+func handleTradeAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			focused = opt
+			break
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	value := focused.StringValue()
+	prefix := ""
+	current := value
+	if idx := strings.LastIndex(value, ","); idx >= 0 {
+		prefix = strings.TrimSpace(value[:idx]) + ", "
+		current = value[idx+1:]
+	}
+	current = strings.ToLower(strings.TrimSpace(current))
+
+	chosen := make(map[string]bool)
+	for _, name := range splitPlayerList(prefix) {
+		chosen[strings.ToLower(name)] = true
+	}
+
+	players, err := leaguePlayers()
+	if err != nil {
+		logError(interactionName(i), "Error loading rosters for autocomplete: %v", err)
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, 25)
+	for _, p := range players {
+		lower := strings.ToLower(p.Name)
+		if chosen[lower] || !strings.Contains(lower, current) {
+			continue
+		}
+		choiceValue := prefix + p.Name
+		if len(choiceValue) > 100 {
+			continue
+		}
+		label := fmt.Sprintf("%s (%s) - %s", choiceValue, p.ProTeam, p.TeamName)
+		if len(label) > 100 {
+			label = choiceValue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: choiceValue})
+		if len(choices) == 25 {
+			break
+		}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		logError(interactionName(i), "Error responding to trade autocomplete: %v", err)
+	}
+}
+
+// tradeAnalysis mirrors the API's POST /api/v1/analytics/trade response
+type tradeAnalysis struct {
+	Teams []struct {
+		TeamName   string   `json:"team_name"`
+		Sends      []string `json:"sends"`
+		Receives   []string `json:"receives"`
+		Gained     int      `json:"categories_gained"`
+		Lost       int      `json:"categories_lost"`
+		Categories []struct {
+			Category string  `json:"category"`
+			Before   float64 `json:"before"`
+			After    float64 `json:"after"`
+			Change   float64 `json:"change"`
+			Improved bool    `json:"improved"`
+		} `json:"categories"`
+	} `json:"teams"`
+}
+
+func handleTradeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var give, get []string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "give":
+			give = splitPlayerList(opt.StringValue())
+		case "get":
+			get = splitPlayerList(opt.StringValue())
+		}
+	}
+
+	// ESPN lookups can take longer than the 3 second interaction window
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		logError(interactionName(i), "Error deferring trade response: %v", err)
+		return
+	}
+
+	var analysis tradeAnalysis
+	content := ""
+	if err := apiPost(leaguePath("/trade", "/api/v1/analytics/trade"), map[string][]string{"give": give, "get": get}, &analysis); err != nil {
+		logError(interactionName(i), "Error analyzing trade: %v", err)
+		content = fmt.Sprintf("❌ Could not analyze trade: %v", err)
+	} else {
+		content = formatTradeAnalysis(analysis)
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		logError(interactionName(i), "Error sending trade analysis: %v", err)
+	}
+}
+
+// formatTradeAnalysis renders a before/after category table for each team
+func formatTradeAnalysis(analysis tradeAnalysis) string {
+	var b strings.Builder
+	b.WriteString("🔄 **Trade Analysis**\n")
+
+	for _, team := range analysis.Teams {
+		fmt.Fprintf(&b, "\n**%s** sends %s, receives %s (+%d / -%d categories)\n",
+			team.TeamName, strings.Join(team.Sends, ", "), strings.Join(team.Receives, ", "), team.Gained, team.Lost)
+
+		b.WriteString("```\n")
+		fmt.Fprintf(&b, "%-4s %8s %8s %8s\n", "CAT", "BEFORE", "AFTER", "CHANGE")
+		for _, cat := range team.Categories {
+			marker := " "
+			if cat.Improved {
+				marker = "▲"
+			} else if cat.Change != 0 {
+				marker = "▼"
+			}
+			fmt.Fprintf(&b, "%-4s %8s %8s %8s %s\n", cat.Category,
+				formatCategoryValue(cat.Category, cat.Before),
+				formatCategoryValue(cat.Category, cat.After),
+				formatCategoryChange(cat.Category, cat.Change),
+				marker)
+		}
+		b.WriteString("```")
+	}
+
+	return b.String()
+}
+
+// formatCategoryValue prints percentages as .xxx and counting stats to one decimal
+func formatCategoryValue(category string, v float64) string {
+	if strings.HasSuffix(category, "%") {
+		return formatPct(v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func formatCategoryChange(category string, v float64) string {
+	if strings.HasSuffix(category, "%") {
+		s := fmt.Sprintf("%+.3f", v)
+		return strings.Replace(s, "0.", ".", 1)
+	}
+	return fmt.Sprintf("%+.1f", v)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// transactionMove mirrors one move of a league transaction from the API
+type transactionMove struct {
+	Action       string `json:"action"`
+	PlayerESPNID int    `json:"player_espn_id"`
+	PlayerName   string `json:"player_name"`
+	TeamName     string `json:"team_name"`
+	FromTeamName string `json:"from_team_name"`
+	Waiver       bool   `json:"waiver"`
+}
+
+// transactionGrade mirrors one team's grade for a transaction
+type transactionGrade struct {
+	TeamName string  `json:"team_name"`
+	Net      float64 `json:"net"`
+	Grade    string  `json:"grade"`
+}
+
+// leagueTransaction mirrors GET /api/v1/leagues/{id}/transactions/pending
+// entries
+type leagueTransaction struct {
+	ID           int                `json:"id"`
+	Type         string             `json:"type"`
+	OccurredAt   time.Time          `json:"occurred_at"`
+	Moves        []transactionMove  `json:"moves"`
+	Grades       []transactionGrade `json:"grades"`
+	RecentValues map[int]float64    `json:"recent_values"`
+}
+
+// postPendingTransactions announces new league moves in the league channel.
+// Each transaction is acknowledged as soon as it is posted, so a failure
+// part way through only retries the remaining ones.
+func postPendingTransactions(s *discordgo.Session) {
+	if leagueID == "" || leagueChannelID == "" {
+		return
+	}
+
+	var data struct {
+		Transactions []leagueTransaction `json:"transactions"`
+	}
+	if err := apiGet("/api/v1/leagues/"+leagueID+"/transactions/pending", &data); err != nil {
+		logError("transactions", "Error fetching pending transactions: %v", err)
+		return
+	}
+
+	for _, t := range data.Transactions {
+		if _, err := s.ChannelMessageSendEmbed(leagueChannelID, transactionEmbed(t)); err != nil {
+			logError("transactions", "Error posting transaction %d: %v", t.ID, err)
+			return
+		}
+
+		path := fmt.Sprintf("/api/v1/leagues/%s/transactions/%d/announced", leagueID, t.ID)
+		if err := apiPost(path, nil, nil); err != nil {
+			logError("transactions", "Error marking transaction %d announced: %v", t.ID, err)
+			return
+		}
+		log.Printf("Announced transaction %d", t.ID)
+	}
+}
+
+func transactionEmbed(t leagueTransaction) *discordgo.MessageEmbed {
+	title, color := "📋 Roster move", 0x95a5a6
+	switch t.Type {
+	case "TRADE":
+		title, color = "🔄 Trade", 0x9b59b6
+	case "WAIVER":
+		title, color = "📝 Waiver claim", 0x3498db
+	case "FREEAGENT":
+		title, color = "➕ Free agent pickup", 0x2ecc71
+	case "DROP":
+		title, color = "➖ Drop", 0xe74c3c
+	}
+
+	lines := make([]string, 0, len(t.Moves))
+	for _, m := range t.Moves {
+		player := fmt.Sprintf("**%s**%s", playerLabel(m), recentValueText(t.RecentValues, m.PlayerESPNID))
+		switch m.Action {
+		case "ADD":
+			lines = append(lines, fmt.Sprintf("%s adds %s", m.TeamName, player))
+		case "DROP":
+			lines = append(lines, fmt.Sprintf("%s drops %s", m.TeamName, player))
+		case "TRADE":
+			lines = append(lines, fmt.Sprintf("%s sends %s to %s", m.FromTeamName, player, m.TeamName))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: strings.Join(lines, "\n"),
+		Color:       color,
+		Timestamp:   t.OccurredAt.Format(time.RFC3339),
+	}
+
+	if len(t.Grades) > 0 {
+		grades := make([]string, 0, len(t.Grades))
+		for _, g := range t.Grades {
+			grades = append(grades, fmt.Sprintf("**%s**: %s (%+.1f FV/game)", g.TeamName, g.Grade, g.Net))
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Grade", Value: strings.Join(grades, "\n")}}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Grades compare fantasy value per game over the last 14 days"}
+	}
+
+	return embed
+}
+
+func playerLabel(m transactionMove) string {
+	if m.PlayerName == "" {
+		return fmt.Sprintf("Player #%d", m.PlayerESPNID)
+	}
+	return m.PlayerName
+}
+
+func recentValueText(values map[int]float64, playerID int) string {
+	v, ok := values[playerID]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f FV L14)", v)
+}